@@ -0,0 +1,53 @@
+package main
+
+import (
+	"expvar"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"album-api/internal/album"
+)
+
+// defaultCacheEntries is the cache size used when only CACHE_TTL is set.
+const defaultCacheEntries = 1000
+
+// loadCacheOptions reads the album cache settings, the cache is disabled (ok is false) unless CACHE_TTL is set.
+func loadCacheOptions() (opts album.CacheOptions, ok bool, err error) {
+	opts.MaxEntries = defaultCacheEntries
+
+	if value := os.Getenv("CACHE_TTL"); value != "" {
+		if opts.TTL, err = time.ParseDuration(value); err != nil {
+			return opts, false, fmt.Errorf("invalid CACHE_TTL %q: %w", value, err)
+		}
+	}
+	if value := os.Getenv("CACHE_MAX_ENTRIES"); value != "" {
+		if opts.MaxEntries, err = strconv.Atoi(value); err != nil {
+			return opts, false, fmt.Errorf("invalid CACHE_MAX_ENTRIES %q: %w", value, err)
+		}
+	}
+
+	switch {
+	case opts.TTL < 0, opts.MaxEntries < 0:
+		return opts, false, fmt.Errorf("CACHE_TTL and CACHE_MAX_ENTRIES must not be negative")
+	case opts.TTL == 0, opts.MaxEntries == 0:
+		return opts, false, nil
+	}
+	return opts, true, nil
+}
+
+// withCache puts the cache in front of the service when it is enabled,
+// its counters are published as the "album_cache" expvar.
+func withCache(service album.Service, logger *slog.Logger) (album.Service, error) {
+	opts, ok, err := loadCacheOptions()
+	if err != nil || !ok {
+		return service, err
+	}
+
+	logger.Info("album cache enabled", slog.Int("max_entries", opts.MaxEntries), slog.Duration("ttl", opts.TTL))
+	cached := album.NewCachedService(service, opts)
+	expvar.Publish("album_cache", expvar.Func(func() any { return cached.Stats() }))
+	return cached, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"album-api/internal/album"
+	"album-api/internal/catalog"
+)
+
+// importCatalog implements the "import" command.
+// Every rejected row is printed to stderr, the command fails when there is any so scripts notice an incomplete import.
+func importCatalog(ctx context.Context, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	formatName := flags.String("format", "", "csv or jsonl, guessed from the file extension by default")
+	batchSize := flags.Int("batch", catalog.DefaultBatchSize, "number of albums added per transaction")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("import needs exactly one file\n" + usage)
+	}
+	path := flags.Arg(0)
+
+	format, err := catalogFormat(*formatName, path)
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	albumService, closeService, err := openService(ctx, logger)
+	if err != nil {
+		return err
+	}
+	defer closeService()
+
+	// The audit log names the command as the author of the imported albums.
+	ctx = album.WithActor(ctx, "import "+path)
+	report, err := catalog.Import(ctx, albumService, in, format, catalog.ImportOptions{BatchSize: *batchSize})
+	for _, rejected := range report.Rejected {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, rejected)
+	}
+	logger.Info("catalog imported", slog.String("path", path), slog.Int("imported", report.Imported), slog.Int("rejected", len(report.Rejected)))
+	if err != nil {
+		return err
+	}
+	if len(report.Rejected) > 0 {
+		return fmt.Errorf("%d row(s) of %s were rejected", len(report.Rejected), path)
+	}
+	return nil
+}
+
+// exportCatalog implements the "export" command.
+func exportCatalog(ctx context.Context, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatName := flags.String("format", "", "csv or jsonl, guessed from the file extension by default and csv on stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("export takes at most one file\n" + usage)
+	}
+	path := flags.Arg(0)
+	if path == "" {
+		path = "-"
+	}
+
+	format := catalog.CSV
+	if path != "-" || *formatName != "" {
+		var err error
+		if format, err = catalogFormat(*formatName, path); err != nil {
+			return err
+		}
+	}
+
+	albumService, closeService, err := openService(ctx, logger)
+	if err != nil {
+		return err
+	}
+	defer closeService()
+
+	if path == "-" {
+		_, err := catalog.Export(ctx, albumService, os.Stdout, format)
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	exported, err := catalog.Export(ctx, albumService, file, format)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("catalog exported", slog.String("path", path), slog.Int("exported", exported))
+	return nil
+}
+
+// catalogFormat returns the format named by the -format flag, or the one of the file extension when the flag is empty.
+func catalogFormat(name, path string) (catalog.Format, error) {
+	if name != "" {
+		return catalog.ParseFormat(name)
+	}
+	if path == "-" {
+		return "", errors.New("-format is required when reading stdin")
+	}
+	return catalog.FormatFromPath(path)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"expvar"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// newDebugServer returns the server of GET /debug/vars, listening on DEBUG_ADDR, or nil when it is unset.
+// The expvars include the command line and the memory statistics, so DEBUG_ADDR should be an address
+// only the operators reach, like localhost:6060, never the one of the API.
+func newDebugServer(logger *slog.Logger) *http.Server {
+	addr := os.Getenv("DEBUG_ADDR")
+	if addr == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+}
+
+// runDebugServer serves until the server is shut down, a failure only costs the metrics and is logged.
+func runDebugServer(logger *slog.Logger, server *http.Server) {
+	logger.Info("debug server listening", slog.String("addr", server.Addr))
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("debug server stopped", slog.Any("error", err))
+	}
+}
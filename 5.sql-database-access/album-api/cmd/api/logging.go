@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"album-api/internal/logging"
+)
+
+// newLogger builds the logger of every command from LOG_FORMAT (text or json) and LOG_LEVEL (debug, info, warn or error),
+// the logs go to stderr so they never mix with an export written to stdout.
+func newLogger() (*slog.Logger, error) {
+	format := logging.Text
+	if value := os.Getenv("LOG_FORMAT"); value != "" {
+		var err error
+		if format, err = logging.ParseFormat(value); err != nil {
+			return nil, err
+		}
+	}
+
+	var level slog.Level
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		if err := level.UnmarshalText([]byte(value)); err != nil {
+			return nil, fmt.Errorf("invalid LOG_LEVEL %q: %w", value, err)
+		}
+	}
+
+	return logging.New(os.Stderr, format, level), nil
+}
@@ -1,34 +1,198 @@
 package main
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"album-api/internal/album"
 	"album-api/internal/database"
+	"album-api/internal/logging"
 )
 
+const (
+	// requestTimeout is the deadline every request (and the queries it runs) has to finish within.
+	requestTimeout = 5 * time.Second
+	// shutdownTimeout is how long in-flight requests get to finish after a shutdown signal.
+	shutdownTimeout = 15 * time.Second
+)
+
+const usage = `usage: album-api [command]
+
+commands:
+  serve                 start the HTTP server (default)
+  migrate up            apply all pending migrations
+  migrate down [-steps] revert the latest migrations (default 1)
+  migrate status        list the migrations and whether they are applied
+  import [-format] [-batch] FILE
+                        add the albums of a CSV or JSON Lines catalog, "-" reads stdin
+  export [-format] [FILE]
+                        write all the albums as CSV or JSON Lines, to stdout by default`
+
 func main() {
+	logger, err := newLogger()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	// The packages without a logger of their own (the database connection and migrations) log through the default one.
+	slog.SetDefault(logger)
+
+	// ctx is cancelled on Ctrl+C or SIGTERM, which starts the graceful shutdown of whatever command is running.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		err = serve(ctx, logger)
+	case "migrate":
+		err = migrate(ctx, logger, args)
+	case "import":
+		err = importCatalog(ctx, logger, args)
+	case "export":
+		err = exportCatalog(ctx, logger, args)
+	default:
+		err = fmt.Errorf("unknown command %q\n%s", command, usage)
+	}
+	if err != nil {
+		logger.Error("command failed", slog.String("command", command), slog.Any("error", err))
+		stop()
+		os.Exit(1)
+	}
+}
+
+// openDatabase loads the database config and connects to it.
+func openDatabase(ctx context.Context, logger *slog.Logger) (*sql.DB, database.Config, error) {
+	dbConfig, err := database.LoadConfig()
+	if err != nil {
+		return nil, dbConfig, fmt.Errorf("invalid database configuration: %w", err)
+	}
+	// Retries are bounded by the config, ctx only stops them early on a shutdown signal.
+	db, err := database.NewConnection(ctx, dbConfig, logger)
+	if err != nil {
+		return nil, dbConfig, fmt.Errorf("couldn't connnect to the database: %w", err)
+	}
+	return db, dbConfig, nil
+}
+
+// openService connects to the database, migrates it when enabled and builds the album service on top of it.
+// The returned func releases the repository and the connection pool, it is nil when an error is returned.
+func openService(ctx context.Context, logger *slog.Logger) (album.Service, func(), error) {
 	// Database Layer
-	db, err := database.NewConnection()
+	db, dbConfig, err := openDatabase(ctx, logger)
 	if err != nil {
-		log.Fatalf("couldn't connnect to the database: %v", err)
+		return nil, nil, err
+	}
+	logger.Info("database connected", slog.String("driver", dbConfig.Driver))
+
+	if dbConfig.AutoMigrate {
+		if err := migrateUp(ctx, logger, db, dbConfig.Driver); err != nil {
+			db.Close()
+			return nil, nil, err
+		}
 	}
-	log.Println("MySQL DB connected and ready for operation.")
 
 	// Repository Layer
-	albumRepo := album.NewRepository(db)
+	var albumRepo album.Repository
+	switch dbConfig.Driver {
+	case database.DriverSQLite:
+		albumRepo = album.NewSQLiteRepository(db, logger)
+	default:
+		albumRepo = album.NewMySQLRepository(db, logger)
+	}
+
+	closeService := func() {
+		// The prepared statements have to be released while the pool is still open.
+		if closer, ok := albumRepo.(io.Closer); ok {
+			closer.Close()
+		}
+		db.Close()
+	}
 
 	// Service Layer
-	albumService := album.NewService(albumRepo)
+	return album.NewService(albumRepo, logger), closeService, nil
+}
+
+// serve runs the HTTP server until ctx is cancelled.
+func serve(ctx context.Context, logger *slog.Logger) error {
+	albumService, closeService, err := openService(ctx, logger)
+	if err != nil {
+		return err
+	}
+	defer closeService()
+
+	// Cache Layer, only the server reads the same albums over and over.
+	albumService, err = withCache(albumService, logger)
+	if err != nil {
+		return err
+	}
 
 	// Handler(Controller) Layer
-	albumHandler := album.NewHandler(albumService)
+	albumHandler := album.NewHandler(albumService, logger)
+
+	mux := http.NewServeMux()
+	albumHandler.RegisterRoutes(mux)
 
-	// In a production grade application, you would start an http server here and pass this albumHandler to it.
-	// Here we just simulate manual API calls, in reality these would be created by the client and would reach the controllers
+	addr := os.Getenv("HTTP_ADDR")
+	if addr == "" {
+		addr = ":8080"
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           logging.Middleware(logger, withTimeout(mux, requestTimeout)),
+		ReadHeaderTimeout: 5 * time.Second,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("HTTP server listening", slog.String("addr", addr))
+		serverErr <- server.ListenAndServe()
+	}()
+	debugServer := newDebugServer(logger)
+	if debugServer != nil {
+		go runDebugServer(logger, debugServer)
+	}
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("http server stopped: %w", err)
+	case <-ctx.Done():
+	}
+	logger.Info("shutting down, waiting for in-flight requests")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if debugServer != nil {
+		debugServer.Shutdown(shutdownCtx)
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+	return nil
+}
 
-	log.Println("\n--- Running Application ---")
-	albumHandler.AddNewAlbum("Sajna", "Pujan Khunt", 399.31)
-	albumHandler.GetAlbumByID(5)
-	albumHandler.GetAlbumsByArtist("Pujan Khunt")
+// withTimeout puts a deadline on the context of every request.
+// The request context is already cancelled by net/http when the client disconnects,
+// both end up in the repository and abort the running query.
+func withTimeout(next http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
 }
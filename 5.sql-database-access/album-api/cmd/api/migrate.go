@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"album-api/internal/database"
+)
+
+// migrate implements the "migrate up|down|status" command.
+func migrate(ctx context.Context, logger *slog.Logger, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing migrate action\n" + usage)
+	}
+	action, args := args[0], args[1:]
+
+	flags := flag.NewFlagSet("migrate "+action, flag.ContinueOnError)
+	steps := flags.Int("steps", 1, "number of migrations to revert (down only)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, dbConfig, err := openDatabase(ctx, logger)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch action {
+	case "up":
+		return migrateUp(ctx, logger, db, dbConfig.Driver)
+	case "down":
+		migrator, err := database.NewMigrator(db, dbConfig.Driver, logger)
+		if err != nil {
+			return err
+		}
+		reverted, err := migrator.Down(ctx, *steps)
+		logger.Info("migrations reverted", slog.Int("reverted", reverted))
+		return err
+	case "status":
+		return migrateStatus(ctx, logger, db, dbConfig.Driver)
+	default:
+		return fmt.Errorf("unknown migrate action %q\n%s", action, usage)
+	}
+}
+
+// migrateUp applies all the pending migrations.
+func migrateUp(ctx context.Context, logger *slog.Logger, db *sql.DB, driver string) error {
+	migrator, err := database.NewMigrator(db, driver, logger)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Info("schema is up to date", slog.Int("applied", applied))
+	return nil
+}
+
+// migrateStatus prints a table of the migrations and when they were applied.
+func migrateStatus(ctx context.Context, logger *slog.Logger, db *sql.DB, driver string) error {
+	migrator, err := database.NewMigrator(db, driver, logger)
+	if err != nil {
+		return err
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", status.Migration, appliedAt)
+	}
+	return w.Flush()
+}
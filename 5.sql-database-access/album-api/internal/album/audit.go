@@ -0,0 +1,92 @@
+package album
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// The actions recorded in the audit log.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+// AuditEntry is one change of an album. The log is append-only, entries are never updated or deleted.
+type AuditEntry struct {
+	ID      int64  `json:"id"`
+	AlbumID int64  `json:"album_id"`
+	Action  string `json:"action"`
+	// Actor is whoever made the change, see WithActor.
+	Actor string `json:"actor"`
+	// Before and After are the album as it was before and after the change, Before is nil for a create.
+	Before *Album    `json:"before,omitempty"`
+	After  *Album    `json:"after,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// newAuditEntry describes a change made with ctx, the album id and the time come from the album after the change,
+// or before it for a change without an after.
+func newAuditEntry(ctx context.Context, action string, before, after *Album) AuditEntry {
+	entry := AuditEntry{Action: action, Actor: ActorFrom(ctx), Before: before, After: after}
+	switch {
+	case after != nil:
+		entry.AlbumID, entry.At = after.ID, after.UpdatedAt
+	case before != nil:
+		entry.AlbumID, entry.At = before.ID, timestamp()
+	}
+	return entry
+}
+
+// marshalAuditAlbum encodes an album of the audit log as JSON, a nil album becomes NULL.
+func marshalAuditAlbum(album *Album) (any, error) {
+	if album == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(album)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// unmarshalAuditAlbum decodes an album written by marshalAuditAlbum.
+func unmarshalAuditAlbum(data []byte) (*Album, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var album Album
+	if err := json.Unmarshal(data, &album); err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+// Anonymous is the actor of the changes made with a context without one.
+const Anonymous = "anonymous"
+
+// maxActorLength mirrors the actor VARCHAR(255) column of the album_audit table.
+const maxActorLength = 255
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx naming who makes the changes done with it, the audit log records that name.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor stored by WithActor, or Anonymous.
+func ActorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return Anonymous
+}
+
+// timestamp returns the current time as stored by the repositories: in UTC and rounded down to the microsecond,
+// the precision of the MySQL DATETIME(6) columns.
+func timestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
@@ -0,0 +1,188 @@
+package album
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheOptions bounds the album cache of a CachedService.
+type CacheOptions struct {
+	// TTL is how long a cached album is served before it is read again, it bounds how stale an album can get
+	// when another process (or a cache-less instance) changes it.
+	TTL time.Duration
+	// MaxEntries is the number of albums kept, the least recently used one is evicted to make room for a new one.
+	MaxEntries int
+}
+
+// CacheStats counts the cache lookups since the CachedService was created.
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+}
+
+// CachedService decorates a Service with a read-through cache of GetAlbum.
+// Every write going through it invalidates the albums it touches, the other methods are passed on as is.
+// It is safe for concurrent use.
+type CachedService struct {
+	Service
+	opts CacheOptions
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[int64]*list.Element
+	// lru holds the *cacheEntry values, most recently used first.
+	lru *list.List
+	// generation is incremented by every invalidation. A GetAlbum only caches what it read when no invalidation
+	// happened meanwhile, otherwise it could store an album older than the write that just invalidated it.
+	generation uint64
+
+	hits, misses, evictions atomic.Uint64
+}
+
+type cacheEntry struct {
+	id      int64
+	album   Album
+	expires time.Time
+}
+
+// NewCachedService returns next with a cache of at most opts.MaxEntries albums, each one kept for opts.TTL.
+func NewCachedService(next Service, opts CacheOptions) *CachedService {
+	return &CachedService{
+		Service: next,
+		opts:    opts,
+		now:     time.Now,
+		entries: make(map[int64]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Stats returns the current counters.
+func (c *CachedService) Stats() CacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Entries:   entries,
+	}
+}
+
+// GetAlbum implements Service, answering from the cache when it holds a fresh copy of the album.
+// Errors, including ErrNotFound, are never cached.
+func (c *CachedService) GetAlbum(ctx context.Context, id int64) (*Album, error) {
+	cached, generation, ok := c.lookup(id)
+	if ok {
+		c.hits.Add(1)
+		return cached, nil
+	}
+	c.misses.Add(1)
+
+	album, err := c.Service.GetAlbum(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.store(*album, generation)
+	return album, nil
+}
+
+// lookup returns a copy of the cached album and marks it as recently used.
+// The returned generation is the one to hand to store after a miss.
+func (c *CachedService) lookup(id int64) (*Album, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[id]
+	if !ok {
+		return nil, c.generation, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(elem)
+		c.evictions.Add(1)
+		return nil, c.generation, false
+	}
+
+	c.lru.MoveToFront(elem)
+	album := entry.album
+	return &album, c.generation, true
+}
+
+// store caches an album read at the given generation, unless it has been invalidated since.
+func (c *CachedService) store(album Album, generation uint64) {
+	if c.opts.MaxEntries <= 0 || c.opts.TTL <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	if elem, ok := c.entries[album.ID]; ok {
+		c.remove(elem)
+	}
+	for c.lru.Len() >= c.opts.MaxEntries {
+		c.remove(c.lru.Back())
+		c.evictions.Add(1)
+	}
+	c.entries[album.ID] = c.lru.PushFront(&cacheEntry{id: album.ID, album: album, expires: c.now().Add(c.opts.TTL)})
+}
+
+// invalidate drops an album from the cache, the caller has already written it.
+func (c *CachedService) invalidate(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if elem, ok := c.entries[id]; ok {
+		c.remove(elem)
+	}
+}
+
+// remove drops an element, the caller holds the lock.
+func (c *CachedService) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).id)
+}
+
+// UpdateAlbum implements Service.
+func (c *CachedService) UpdateAlbum(ctx context.Context, album Album) (*Album, error) {
+	// Invalidated even when the update fails, it may have failed after the commit (e.g. a timeout while reading it back).
+	defer c.invalidate(album.ID)
+	return c.Service.UpdateAlbum(ctx, album)
+}
+
+// PatchAlbum implements Service.
+func (c *CachedService) PatchAlbum(ctx context.Context, id int64, version int64, patch AlbumPatch) (*Album, error) {
+	defer c.invalidate(id)
+	return c.Service.PatchAlbum(ctx, id, version, patch)
+}
+
+// DeleteAlbum implements Service.
+func (c *CachedService) DeleteAlbum(ctx context.Context, id int64) error {
+	defer c.invalidate(id)
+	return c.Service.DeleteAlbum(ctx, id)
+}
+
+// RestoreAlbum implements Service.
+func (c *CachedService) RestoreAlbum(ctx context.Context, id int64) (*Album, error) {
+	defer c.invalidate(id)
+	return c.Service.RestoreAlbum(ctx, id)
+}
+
+// CreateAlbum implements Service.
+// A new id can't be cached yet, but the generation still moves so no read racing with the write caches anything stale.
+func (c *CachedService) CreateAlbum(ctx context.Context, album Album) (int64, error) {
+	id, err := c.Service.CreateAlbum(ctx, album)
+	c.invalidate(id)
+	return id, err
+}
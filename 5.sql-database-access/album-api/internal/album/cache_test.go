@@ -0,0 +1,172 @@
+package album
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingService counts the GetAlbum calls reaching the wrapped service.
+type countingService struct {
+	Service
+	gets int
+}
+
+func (s *countingService) GetAlbum(ctx context.Context, id int64) (*Album, error) {
+	s.gets++
+	return s.Service.GetAlbum(ctx, id)
+}
+
+// newTestCache returns a cache over a memory repository holding the given albums, with a clock the test moves by hand.
+func newTestCache(t *testing.T, opts CacheOptions, albums ...Album) (*CachedService, *countingService, *time.Time, []int64) {
+	t.Helper()
+	next := &countingService{Service: NewService(NewMemoryRepository(), testLogger(t))}
+	ids := make([]int64, len(albums))
+	for i, album := range albums {
+		id, err := next.CreateAlbum(t.Context(), album)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = id
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewCachedService(next, opts)
+	cache.now = func() time.Time { return now }
+	return cache, next, &now, ids
+}
+
+func TestCachedService_ReadThrough(t *testing.T) {
+	cache, next, now, ids := newTestCache(t, CacheOptions{TTL: time.Minute, MaxEntries: 10},
+		Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+	id := ids[0]
+
+	for range 3 {
+		album, err := cache.GetAlbum(t.Context(), id)
+		if err != nil {
+			t.Fatalf("GetAlbum() error = %v", err)
+		}
+		if album.Title != "Jeru" {
+			t.Errorf("GetAlbum() = %+v; want Jeru", album)
+		}
+		// The callers get their own copy, changing it doesn't touch the cache.
+		album.Title = "changed"
+	}
+	if next.gets != 1 {
+		t.Errorf("service read %d times; want 1", next.gets)
+	}
+
+	*now = now.Add(time.Minute)
+	if _, err := cache.GetAlbum(t.Context(), id); err != nil {
+		t.Fatal(err)
+	}
+	if next.gets != 2 {
+		t.Errorf("service read %d times after the TTL; want 2", next.gets)
+	}
+
+	want := CacheStats{Hits: 2, Misses: 2, Evictions: 1, Entries: 1}
+	if got := cache.Stats(); got != want {
+		t.Errorf("Stats() = %+v; want %+v", got, want)
+	}
+}
+
+func TestCachedService_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache, next, _, ids := newTestCache(t, CacheOptions{TTL: time.Minute, MaxEntries: 2},
+		Album{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")},
+		Album{Title: "Giant Steps", Artist: "John Coltrane", Price: usd("63.99")},
+		Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+
+	// ids[1] is the least recently used one when ids[2] comes in.
+	for _, id := range []int64{ids[0], ids[1], ids[0], ids[2]} {
+		if _, err := cache.GetAlbum(t.Context(), id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	next.gets = 0
+
+	tests := []struct {
+		id       int64
+		wantGets int
+	}{
+		{ids[0], 0},
+		{ids[2], 0},
+		{ids[1], 1},
+	}
+	for _, tc := range tests {
+		if _, err := cache.GetAlbum(t.Context(), tc.id); err != nil {
+			t.Fatal(err)
+		}
+		if next.gets != tc.wantGets {
+			t.Errorf("GetAlbum(%d) read the service %d times; want %d", tc.id, next.gets, tc.wantGets)
+		}
+		next.gets = 0
+	}
+	if stats := cache.Stats(); stats.Entries != 2 || stats.Evictions != 2 {
+		t.Errorf("Stats() = %+v; want 2 entries and 2 evictions", stats)
+	}
+}
+
+func TestCachedService_Invalidation(t *testing.T) {
+	cache, _, _, ids := newTestCache(t, CacheOptions{TTL: time.Hour, MaxEntries: 10},
+		Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+	id := ids[0]
+
+	get := func() (*Album, error) {
+		t.Helper()
+		return cache.GetAlbum(t.Context(), id)
+	}
+
+	if _, err := get(); err != nil {
+		t.Fatal(err)
+	}
+	price := usd("19.99")
+	if _, err := cache.PatchAlbum(t.Context(), id, AnyVersion, AlbumPatch{Price: &price}); err != nil {
+		t.Fatal(err)
+	}
+	if album, _ := get(); album.Price != price || album.Version != 2 {
+		t.Errorf("GetAlbum() after a patch = %+v; want the patched album", album)
+	}
+
+	if _, err := cache.UpdateAlbum(t.Context(), Album{ID: id, Title: "Jeru (Remastered)", Artist: "Gerry Mulligan", Price: price}); err != nil {
+		t.Fatal(err)
+	}
+	if album, _ := get(); album.Title != "Jeru (Remastered)" {
+		t.Errorf("GetAlbum() after an update = %+v; want the updated album", album)
+	}
+
+	if err := cache.DeleteAlbum(t.Context(), id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetAlbum() after a delete error = %v; want ErrNotFound", err)
+	}
+
+	if _, err := cache.RestoreAlbum(t.Context(), id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(); err != nil {
+		t.Errorf("GetAlbum() after a restore error = %v", err)
+	}
+}
+
+func TestCachedService_SkipsReadsRacingWrites(t *testing.T) {
+	cache, _, _, ids := newTestCache(t, CacheOptions{TTL: time.Hour, MaxEntries: 10},
+		Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+	id := ids[0]
+
+	// A read misses, then a write lands before the read stores what it got.
+	_, generation, _ := cache.lookup(id)
+	stale, err := cache.Service.GetAlbum(t.Context(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.DeleteAlbum(t.Context(), id); err != nil {
+		t.Fatal(err)
+	}
+	cache.store(*stale, generation)
+
+	if _, err := cache.GetAlbum(t.Context(), id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetAlbum() error = %v; want ErrNotFound, the stale read must not be cached", err)
+	}
+}
@@ -0,0 +1,99 @@
+package album
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// dialect holds the behaviour of sqlRepository that depends on the database engine.
+type dialect interface {
+	// classify Adds the matching domain error to the driver errors the service and handler have to react to.
+	// Both the domain error and the original one stay in the chain.
+	classify(err error) error
+
+	// searchQuery returns the query run by SearchAlbums for the lower-case terms.
+	// ranked tells whether the database already orders the rows by relevance and applies the limit,
+	// otherwise the query returns every candidate and the repository ranks them with rankAlbums.
+	searchQuery(terms []string, limit int) (query string, args []any, ranked bool)
+
+	// price returns the expression the prices are filtered and ordered by, a number in major units.
+	price() string
+
+	// lockRow turns a query reading one album into one locking its row until the end of the transaction,
+	// so the version read is still the stored one when the write comes.
+	lockRow(query string) string
+}
+
+// mySQLErrDuplicateEntry is the server error number for a violated PRIMARY KEY or UNIQUE index (ER_DUP_ENTRY).
+const mySQLErrDuplicateEntry = 1062
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) classify(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mySQLErrDuplicateEntry {
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+	return err
+}
+
+// searchQuery uses the FULLTEXT index on (title, artist) in boolean mode: "+blue* +tra*" requires every term
+// as the start of a word. The MATCH in the select list reuses the one in WHERE, MySQL computes the relevance only once.
+// Words shorter than innodb_ft_min_token_size (3 by default) and InnoDB stopwords like "the" or "of" are not indexed,
+// a search for them finds nothing.
+func (mysqlDialect) searchQuery(terms []string, limit int) (string, []any, bool) {
+	expr := make([]string, len(terms))
+	for i, term := range terms {
+		expr[i] = "+" + term + "*"
+	}
+	against := strings.Join(expr, " ")
+
+	query := "SELECT " + albumColumns + " FROM album" +
+		" WHERE MATCH(title, artist) AGAINST (? IN BOOLEAN MODE) AND " + notDeleted +
+		" ORDER BY MATCH(title, artist) AGAINST (? IN BOOLEAN MODE) DESC, id LIMIT ?"
+	return query, []any{against, against, limit}, true
+}
+
+func (mysqlDialect) price() string { return "price" }
+
+// lockRow adds FOR UPDATE, which also reads the latest committed row instead of the snapshot of the transaction.
+func (mysqlDialect) lockRow(query string) string { return query + " FOR UPDATE" }
+
+type sqliteDialect struct{}
+
+// price converts the TEXT column, see migration 0007, ordering the strings would put "9.50" after "399.31".
+func (sqliteDialect) price() string { return "CAST(price AS REAL)" }
+
+// lockRow leaves the query alone, SQLite has no row locks: a transaction whose reads went stale
+// fails when it tries to write (SQLITE_BUSY) instead of writing over a concurrent change.
+func (sqliteDialect) lockRow(query string) string { return query }
+
+func (sqliteDialect) classify(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return fmt.Errorf("%w: %w", ErrConflict, err)
+		}
+	}
+	return err
+}
+
+// searchQuery narrows the candidates down with LIKE, every term has to appear somewhere in the title or the artist.
+// SQLite only folds the case of ASCII letters in LIKE, so a term with other capital letters may miss albums.
+func (sqliteDialect) searchQuery(terms []string, _ int) (string, []any, bool) {
+	conditions := []string{notDeleted}
+	args := make([]any, 0, 2*len(terms))
+	for _, term := range terms {
+		conditions = append(conditions, "(title LIKE ? ESCAPE '!' OR artist LIKE ? ESCAPE '!')")
+		pattern := "%" + escapeLike(term) + "%"
+		args = append(args, pattern, pattern)
+	}
+	query := "SELECT " + albumColumns + " FROM album WHERE " + strings.Join(conditions, " AND ")
+	return query, args, false
+}
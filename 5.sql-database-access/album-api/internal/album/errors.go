@@ -0,0 +1,29 @@
+package album
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The errors below are returned wrapped (with %w) by the repository and the service,
+// so callers should compare against them with errors.Is instead of ==.
+// The underlying driver error stays in the chain too and can be extracted with errors.As.
+var (
+	// ErrNotFound is returned when the requested album doesn't exist.
+	ErrNotFound = errors.New("album not found")
+
+	// ErrValidation is returned when an album, or the options of a request, break the rules enforced by the service.
+	ErrValidation = errors.New("validation failed")
+
+	// ErrConflict is returned when a write clashes with the data already stored, e.g. a duplicate key.
+	ErrConflict = errors.New("album conflict")
+
+	// ErrVersionMismatch is returned when an update carries an older version than the stored album,
+	// i.e. somebody else changed the album in the meantime. It matches ErrConflict too.
+	ErrVersionMismatch = fmt.Errorf("%w: version mismatch", ErrConflict)
+)
+
+// versionMismatchError wraps ErrVersionMismatch with the versions involved.
+func versionMismatchError(expected, current int64) error {
+	return fmt.Errorf("%w: version %d was expected but the album is at version %d", ErrVersionMismatch, expected, current)
+}
@@ -1,46 +1,422 @@
 package album
 
-import "log"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"example/money"
+)
 
 type Handler struct {
 	service Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+// RegisterRoutes registers all the album endpoints on the given mux.
+// The method and path wildcards in the patterns need Go 1.22 or newer.
+func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	handle := func(pattern string, handler http.HandlerFunc) {
+		mux.Handle(pattern, h.withActor(handler))
+	}
+	handle("POST /albums", h.AddNewAlbum)
+	handle("GET /albums", h.GetAlbums)
+	handle("GET /albums/search", h.SearchAlbums)
+	handle("GET /albums/{id}", h.GetAlbumByID)
+	handle("PUT /albums/{id}", h.UpdateAlbum)
+	handle("PATCH /albums/{id}", h.PatchAlbum)
+	handle("DELETE /albums/{id}", h.DeleteAlbum)
+	handle("POST /albums/{id}/restore", h.RestoreAlbum)
+	handle("GET /albums/{id}/history", h.GetAlbumHistory)
+}
+
+// ActorHeader names who makes a request, it ends up in the audit log of the albums the request changes.
+// The API has no authentication, so the name is whatever the client claims: the actor of an entry is advisory,
+// good for telling the changes apart but not as proof of who made them.
+const ActorHeader = "X-Actor"
+
+// withActor hands the ActorHeader of the request to the service through the context.
+func (h *Handler) withActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := strings.TrimSpace(r.Header.Get(ActorHeader))
+		if utf8.RuneCountInString(actor) > maxActorLength {
+			h.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("%s header must not be longer than %d characters", ActorHeader, maxActorLength))
+			return
+		}
+		if actor != "" {
+			r = r.WithContext(WithActor(r.Context(), actor))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetAlbums handles GET /albums and returns one page of albums along with the cursor of the next page.
+//
+// Query parameters (all optional):
+//   - limit: page size, DefaultPageSize by default and at most MaxPageSize
+//   - cursor: the next_cursor of the previous page
+//   - sort: id, title, artist or price, prefixed with "-" for descending order
+//   - artist: exact artist name
+//   - title: text the title has to contain, ignoring case
+//   - min_price, max_price: inclusive decimal bounds in the currency given by currency (USD by default)
+func (h *Handler) GetAlbums(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r.URL.Query())
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.service.ListAlbums(r.Context(), opts)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	// Encode an empty list as [] instead of null.
+	if page.Albums == nil {
+		page.Albums = []Album{}
+	}
+	h.writeJSON(w, r, http.StatusOK, page)
+}
+
+// parseListOptions reads the GetAlbums query parameters, the service validates the values.
+func parseListOptions(query url.Values) (ListOptions, error) {
+	opts := ListOptions{
+		Cursor:        query.Get("cursor"),
+		Artist:        query.Get("artist"),
+		TitleContains: query.Get("title"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return opts, fmt.Errorf("query parameter \"limit\" must be an integer")
+		}
+		opts.Limit = n
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		opts.Descending = strings.HasPrefix(sort, "-")
+		opts.Sort = SortField(strings.TrimPrefix(sort, "-"))
+	}
+
+	currency := query.Get("currency")
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	for _, bound := range []struct {
+		param string
+		dst   **money.Money
+	}{{"min_price", &opts.MinPrice}, {"max_price", &opts.MaxPrice}} {
+		value := query.Get(bound.param)
+		if value == "" {
+			continue
+		}
+		price, err := money.Parse(value, currency)
+		if err != nil {
+			return opts, fmt.Errorf("query parameter %q: %w", bound.param, err)
+		}
+		*bound.dst = &price
+	}
+
+	return opts, nil
 }
 
-func NewHandler(service Service) *Handler {
-	return &Handler{service: service}
+// SearchAlbums handles GET /albums/search?q=blue+tra and returns the matching albums, the most relevant first,
+// as {"albums": [...]}. The optional limit query parameter works like the one of GetAlbums.
+// The pattern is more specific than GET /albums/{id}, so the mux routes it here.
+func (h *Handler) SearchAlbums(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			h.writeError(w, r, http.StatusBadRequest, "query parameter \"limit\" must be an integer")
+			return
+		}
+		limit = n
+	}
+
+	albums, err := h.service.SearchAlbums(r.Context(), query.Get("q"), limit)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	// Encode an empty list as [] instead of null.
+	if albums == nil {
+		albums = []Album{}
+	}
+	h.writeJSON(w, r, http.StatusOK, Page{Albums: albums})
 }
 
-// GetAlbumsByArtist Simulates an API call to get albums by an artist.
-func (h *Handler) GetAlbumsByArtist(artistName string) {
-	log.Printf("HANDLER: fetching albums for artist: %q", artistName)
-	albums, err := h.service.GetAlbumsByArtist(artistName)
+// GetAlbumByID handles GET /albums/{id}.
+func (h *Handler) GetAlbumByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseAlbumID(w, r)
+	if !ok {
+		return
+	}
+
+	album, err := h.service.GetAlbum(r.Context(), id)
 	if err != nil {
-		log.Fatalf("HANDLER ERROR: %v", err)
+		h.handleServiceError(w, r, err)
+		return
 	}
-	log.Printf("HANDLER SUCCESS: Albums Found: %v", albums)
+	w.Header().Set("ETag", etag(album.Version))
+	h.writeJSON(w, r, http.StatusOK, album)
 }
 
-// GetAlbumByID Simulates an API call to get a single album.
-func (h *Handler) GetAlbumByID(id int64) {
-	log.Printf("HANDLER: fetching album with ID: %d", id)
-	album, err := h.service.GetAlbum(id)
+// AddNewAlbum handles POST /albums, the request body is the album encoded as JSON.
+func (h *Handler) AddNewAlbum(w http.ResponseWriter, r *http.Request) {
+	var album Album
+	if !h.decodeBody(w, r, &album, "album") {
+		return
+	}
+
+	albumID, err := h.service.CreateAlbum(r.Context(), album)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	// Read the album back for the values set by the database: its version and timestamps.
+	created, err := h.service.GetAlbum(r.Context(), albumID)
 	if err != nil {
-		log.Fatalf("HANDLER ERROR: %v", err)
+		h.handleServiceError(w, r, err)
+		return
 	}
-	log.Printf("HANDLER SUCCESS: Album Found: %v", album)
+	w.Header().Set("ETag", etag(created.Version))
+	h.writeJSON(w, r, http.StatusCreated, created)
 }
 
-// AddNewAlbum Simulates an API call to add a new album.
-func (h *Handler) AddNewAlbum(title, artist string, price float32) {
-	log.Printf("HANDLER: adding new album")
-	album := Album{
-		Title:  title,
-		Artist: artist,
-		Price:  price,
+// UpdateAlbum handles PUT /albums/{id}, the request body replaces the whole album.
+// The If-Match header holds the ETag of a previous GET, the album is only replaced when nobody changed it
+// in the meantime, otherwise the answer is 412 Precondition Failed. Without the header it is 428 Precondition Required.
+func (h *Handler) UpdateAlbum(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseAlbumID(w, r)
+	if !ok {
+		return
+	}
+	version, ok := h.parseIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var album Album
+	if !h.decodeBody(w, r, &album, "album") {
+		return
 	}
-	albumID, err := h.service.CreateAlbum(album)
+	// The id in the path always wins over the one in the body, and the version only comes from If-Match.
+	album.ID = id
+	album.Version = version
+
+	updated, err := h.service.UpdateAlbum(r.Context(), album)
 	if err != nil {
-		log.Fatalf("HANDLER ERROR: %v", err)
+		h.handleServiceError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", etag(updated.Version))
+	h.writeJSON(w, r, http.StatusOK, updated)
+}
+
+// PatchAlbum handles PATCH /albums/{id}, only the fields present in the request body are changed.
+// If-Match works like for UpdateAlbum.
+func (h *Handler) PatchAlbum(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseAlbumID(w, r)
+	if !ok {
+		return
+	}
+	version, ok := h.parseIfMatch(w, r)
+	if !ok {
+		return
 	}
-	log.Printf("HANDLER SUCCESS: Album added with ID: %d", albumID)
+
+	var patch AlbumPatch
+	if !h.decodeBody(w, r, &patch, "album patch") {
+		return
+	}
+
+	album, err := h.service.PatchAlbum(r.Context(), id, version, patch)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", etag(album.Version))
+	h.writeJSON(w, r, http.StatusOK, album)
+}
+
+// DeleteAlbum handles DELETE /albums/{id} and answers with 204 No Content on success.
+// The album is only marked as deleted and can be brought back with RestoreAlbum.
+func (h *Handler) DeleteAlbum(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseAlbumID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteAlbum(r.Context(), id); err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreAlbum handles POST /albums/{id}/restore and answers with the restored album.
+// Restoring an album which isn't deleted is a 409 Conflict.
+func (h *Handler) RestoreAlbum(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseAlbumID(w, r)
+	if !ok {
+		return
+	}
+
+	album, err := h.service.RestoreAlbum(r.Context(), id)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", etag(album.Version))
+	h.writeJSON(w, r, http.StatusOK, album)
+}
+
+// GetAlbumHistory handles GET /albums/{id}/history and returns the audit log of the album, oldest change first,
+// as {"entries": [...]}. The history of a deleted album is still available, a missing album is a 404.
+func (h *Handler) GetAlbumHistory(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseAlbumID(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.service.AlbumHistory(r.Context(), id)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+	// An album created before the audit log existed has no entries yet.
+	if entries == nil {
+		entries = []AuditEntry{}
+	}
+	h.writeJSON(w, r, http.StatusOK, struct {
+		Entries []AuditEntry `json:"entries"`
+	}{entries})
+}
+
+// parseAlbumID parses the {id} path wildcard, writing a 400 response when it isn't an integer.
+func (h *Handler) parseAlbumID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "album id must be an integer")
+		return 0, false
+	}
+	return id, true
+}
+
+// etag returns the ETag header value of an album version, e.g. "3" with the quotes.
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parseIfMatch returns the album version required by the If-Match header, writing a 400 response when it isn't one of our ETags.
+// A missing header is a 428, so a client unaware of the ETags can't overwrite a change it never saw.
+// Overwriting whatever version is stored takes an explicit "*".
+func (h *Handler) parseIfMatch(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch header {
+	case "":
+		h.writeError(w, r, http.StatusPreconditionRequired, "If-Match is required, send the ETag of the album or * to overwrite any version")
+		return 0, false
+	case "*":
+		return AnyVersion, true
+	}
+
+	if unquoted, err := strconv.Unquote(header); err == nil {
+		if version, err := strconv.ParseInt(unquoted, 10, 64); err == nil && version > 0 {
+			return version, true
+		}
+	}
+	h.writeError(w, r, http.StatusBadRequest, "If-Match must be a single ETag returned by the API, like \"3\"")
+	return 0, false
+}
+
+// decodeBody decodes the JSON request body into v, a what like "album" names it in the 400 response of a malformed body.
+// A price which can't be decoded, like a null or an unknown currency, is a broken rule of the price field answered with a 422.
+func (h *Handler) decodeBody(w http.ResponseWriter, r *http.Request, v any, what string) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, money.ErrInvalid):
+		verr := &ValidationError{}
+		verr.add("price", RuleInvalid, "%s", strings.TrimPrefix(err.Error(), money.ErrInvalid.Error()+": "))
+		h.handleServiceError(w, r, verr)
+	default:
+		h.writeError(w, r, http.StatusBadRequest, "request body must be a valid "+what+": "+err.Error())
+	}
+	return false
+}
+
+// handleServiceError maps the errors returned by the service to HTTP status codes.
+// The client only gets a fixed message per status, the wrapped chain names internal operations and is logged instead.
+// Only the broken rules of a *ValidationError are written for the client and sent as they are.
+func (h *Handler) handleServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		h.logger.WarnContext(r.Context(), "request timed out", slog.Any("error", err))
+		h.writeError(w, r, http.StatusGatewayTimeout, "request timed out")
+	case errors.Is(err, context.Canceled):
+		// The client has gone away, nobody is left to read the response.
+		h.logger.InfoContext(r.Context(), "request cancelled", slog.Any("error", err))
+	case errors.Is(err, ErrNotFound):
+		h.rejectRequest(w, r, http.StatusNotFound, "album not found", err)
+	case errors.Is(err, ErrValidation):
+		// The request was well formed but the album breaks the business rules.
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			h.writeJSON(w, r, http.StatusUnprocessableEntity, errorResponse{Error: ErrValidation.Error(), Fields: verr.Fields})
+			return
+		}
+		h.rejectRequest(w, r, http.StatusUnprocessableEntity, ErrValidation.Error(), err)
+	case errors.Is(err, ErrVersionMismatch):
+		// Only If-Match sets the version a write expects, so a mismatch means its precondition failed.
+		h.rejectRequest(w, r, http.StatusPreconditionFailed, "the album changed since the version in If-Match, fetch it again", err)
+	case errors.Is(err, ErrConflict):
+		h.rejectRequest(w, r, http.StatusConflict, "the request conflicts with the current state of the album", err)
+	default:
+		h.logger.ErrorContext(r.Context(), "album service failed", slog.Any("error", err))
+		h.writeError(w, r, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// rejectRequest answers a request the service turned down with message, the error behind it is only logged.
+func (h *Handler) rejectRequest(w http.ResponseWriter, r *http.Request, status int, message string, err error) {
+	h.logger.InfoContext(r.Context(), "request rejected", slog.Int("status", status), slog.Any("error", err))
+	h.writeError(w, r, status, message)
+}
+
+// errorResponse is the JSON body sent back for every failed request.
+type errorResponse struct {
+	Error string `json:"error"`
+	// Fields lists the broken rules of a 422 Unprocessable Entity response.
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.ErrorContext(r.Context(), "encoding response failed", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	h.writeJSON(w, r, status, errorResponse{Error: message})
 }
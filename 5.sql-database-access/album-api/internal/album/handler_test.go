@@ -0,0 +1,275 @@
+package album
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestServer serves the album routes backed by an in-memory repository.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	NewHandler(NewService(NewMemoryRepository(), testLogger(t)), testLogger(t)).RegisterRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHandler_AddNewAlbum(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantFields []string
+	}{
+		{"Valid album", `{"title": "Sajna", "artist": "Pujan Khunt", "price": {"amount": "399.31", "currency": "USD"}}`, http.StatusCreated, nil},
+		{"Malformed JSON", `{"title": `, http.StatusBadRequest, nil},
+		{"Broken rules", `{"title": "", "artist": "Pujan Khunt", "price": -1}`, http.StatusUnprocessableEntity, []string{"title", "price.amount"}},
+		{"Null price", `{"title": "Sajna", "artist": "Pujan Khunt", "price": null}`, http.StatusUnprocessableEntity, []string{"price"}},
+		{"Unknown currency", `{"title": "Sajna", "artist": "Pujan Khunt", "price": {"amount": 1, "currency": "XX"}}`, http.StatusUnprocessableEntity, []string{"price"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+
+			resp, err := http.Post(server.URL+"/albums", "application/json", strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.wantStatus {
+				t.Fatalf("POST /albums status = %d; want %d", resp.StatusCode, tc.wantStatus)
+			}
+			if tc.wantFields == nil {
+				return
+			}
+
+			var body errorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			var fields []string
+			for _, field := range body.Fields {
+				fields = append(fields, field.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tc.wantFields, ",") {
+				t.Errorf("422 body fields = %v; want %v", fields, tc.wantFields)
+			}
+		})
+	}
+}
+
+func TestHandler_GetAlbumByID(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/albums/1", http.StatusNotFound},
+		{"/albums/abc", http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		resp, err := http.Get(server.URL + tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.wantStatus {
+			t.Errorf("GET %s status = %d; want %d", tc.path, resp.StatusCode, tc.wantStatus)
+		}
+	}
+}
+
+func TestHandler_SearchAlbums(t *testing.T) {
+	server := newTestServer(t)
+	resp, err := http.Post(server.URL+"/albums", "application/json", strings.NewReader(`{"title": "Blue Train", "artist": "John Coltrane", "price": 56.99}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantCount  int
+	}{
+		{"?q=blue+tra", http.StatusOK, 1},
+		{"?q=COLTRANE", http.StatusOK, 1},
+		{"?q=jeru", http.StatusOK, 0},
+		{"", http.StatusUnprocessableEntity, 0},
+		{"?q=blue&limit=x", http.StatusBadRequest, 0},
+	}
+	for _, tc := range tests {
+		resp, err := http.Get(server.URL + "/albums/search" + tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var page Page
+		decodeErr := json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.wantStatus {
+			t.Errorf("GET /albums/search%s status = %d; want %d", tc.query, resp.StatusCode, tc.wantStatus)
+			continue
+		}
+		if tc.wantStatus != http.StatusOK {
+			continue
+		}
+		if decodeErr != nil {
+			t.Fatal(decodeErr)
+		}
+		if page.Albums == nil || len(page.Albums) != tc.wantCount {
+			t.Errorf("GET /albums/search%s = %+v; want %d albums", tc.query, page.Albums, tc.wantCount)
+		}
+	}
+}
+
+func TestHandler_IfMatch(t *testing.T) {
+	server := newTestServer(t)
+	resp, err := http.Post(server.URL+"/albums", "application/json", strings.NewReader(`{"title": "Jeru", "artist": "Gerry Mulligan", "price": 17.99}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("ETag"); got != `"1"` {
+		t.Fatalf("POST /albums ETag = %s; want \"1\"", got)
+	}
+
+	put := func(ifMatch, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/albums/1", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	tests := []struct {
+		name       string
+		ifMatch    string
+		wantStatus int
+		wantETag   string
+	}{
+		{"Current version", `"1"`, http.StatusOK, `"2"`},
+		{"Stale version", `"1"`, http.StatusPreconditionFailed, ""},
+		{"Not an ETag", "1", http.StatusBadRequest, ""},
+		{"No header", "", http.StatusPreconditionRequired, ""},
+		{"Any version", "*", http.StatusOK, `"3"`},
+	}
+	for _, tc := range tests {
+		resp := put(tc.ifMatch, `{"title": "Jeru", "artist": "Gerry Mulligan", "price": 19.99}`)
+		if resp.StatusCode != tc.wantStatus {
+			t.Errorf("%s: PUT status = %d; want %d", tc.name, resp.StatusCode, tc.wantStatus)
+		}
+		if got := resp.Header.Get("ETag"); got != tc.wantETag {
+			t.Errorf("%s: PUT ETag = %s; want %s", tc.name, got, tc.wantETag)
+		}
+	}
+
+	resp, err = http.Get(server.URL + "/albums/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("ETag"); got != `"3"` {
+		t.Errorf("GET /albums/1 ETag = %s; want \"3\"", got)
+	}
+}
+
+func TestHandler_DeleteRestoreHistory(t *testing.T) {
+	server := newTestServer(t)
+	do := func(method, path, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(ActorHeader, "alice")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	steps := []struct {
+		method, path, body string
+		wantStatus         int
+	}{
+		{http.MethodPost, "/albums", `{"title": "Jeru", "artist": "Gerry Mulligan", "price": 17.99}`, http.StatusCreated},
+		{http.MethodDelete, "/albums/1", "", http.StatusNoContent},
+		{http.MethodGet, "/albums/1", "", http.StatusNotFound},
+		{http.MethodPost, "/albums/1/restore", "", http.StatusOK},
+		{http.MethodPost, "/albums/1/restore", "", http.StatusConflict},
+		{http.MethodGet, "/albums/1", "", http.StatusOK},
+		{http.MethodGet, "/albums/2/history", "", http.StatusNotFound},
+	}
+	for _, step := range steps {
+		if resp := do(step.method, step.path, step.body); resp.StatusCode != step.wantStatus {
+			t.Fatalf("%s %s status = %d; want %d", step.method, step.path, resp.StatusCode, step.wantStatus)
+		}
+	}
+
+	resp := do(http.MethodGet, "/albums/1/history", "")
+	var body struct {
+		Entries []AuditEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, entry := range body.Entries {
+		actions = append(actions, entry.Actor+":"+entry.Action)
+	}
+	want := "alice:create,alice:delete,alice:restore"
+	if got := strings.Join(actions, ","); got != want {
+		t.Errorf("GET /albums/1/history = %s; want %s", got, want)
+	}
+}
+
+func TestHandler_ErrorsHideInternals(t *testing.T) {
+	server := newTestServer(t)
+	tests := []struct {
+		method, path string
+		wantStatus   int
+		wantError    string
+	}{
+		{http.MethodGet, "/albums/999", http.StatusNotFound, "album not found"},
+		{http.MethodGet, "/albums/999/history", http.StatusNotFound, "album not found"},
+		{http.MethodDelete, "/albums/999", http.StatusNotFound, "album not found"},
+	}
+	for _, tc := range tests {
+		req, err := http.NewRequest(tc.method, server.URL+tc.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body errorResponse
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tc.wantStatus || body.Error != tc.wantError {
+			t.Errorf("%s %s = %d %q; want %d %q", tc.method, tc.path, resp.StatusCode, body.Error, tc.wantStatus, tc.wantError)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package album
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// memoryRepository implements the Repository interface with a map guarded by a mutex.
+// It is safe for concurrent use and keeps nothing across restarts, which makes it a good fit for tests and local runs.
+type memoryRepository struct {
+	mu     sync.RWMutex
+	albums map[int64]Album
+	lastID int64
+	// audit is the append-only audit log, the id of an entry is its index + 1.
+	audit []AuditEntry
+	// inTx is set on the copy handed to a WithTx callback.
+	inTx bool
+}
+
+// NewMemoryRepository returns an empty Repository keeping the albums in memory.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{albums: make(map[int64]Album)}
+}
+
+// AddAlbum implements Repository. Ids are assigned in increasing order starting at 1, like AUTO_INCREMENT does.
+func (r *memoryRepository) AddAlbum(ctx context.Context, album Album) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, fmt.Errorf("addAlbum: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	now := timestamp()
+	album.ID, album.Version, album.CreatedAt, album.UpdatedAt, album.DeletedAt = r.lastID, firstVersion, now, now, nil
+	r.albums[album.ID] = album
+	r.record(ctx, ActionCreate, nil, &album)
+	return album.ID, nil
+}
+
+// AlbumByID implements Repository.
+func (r *memoryRepository) AlbumByID(ctx context.Context, id int64) (*Album, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("albumById %d: %w", id, err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	album, ok := r.albums[id]
+	if !ok || album.DeletedAt != nil {
+		return nil, fmt.Errorf("albumById %d: %w", id, ErrNotFound)
+	}
+	// album is a copy, the caller can't modify the stored one through the pointer.
+	return &album, nil
+}
+
+// AlbumsByArtist implements Repository.
+func (r *memoryRepository) AlbumsByArtist(ctx context.Context, artistName string) ([]Album, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("albumsByArtist %q: %w", artistName, err)
+	}
+
+	return r.filter(func(album Album) bool { return album.Artist == artistName }), nil
+}
+
+// ListAlbums implements Repository.
+func (r *memoryRepository) ListAlbums(ctx context.Context, opts ListOptions) (Page, error) {
+	if err := ctx.Err(); err != nil {
+		return Page{}, fmt.Errorf("listAlbums: %w", err)
+	}
+
+	opts.Sort = opts.sortField()
+	if _, ok := sortColumns[opts.Sort]; !ok {
+		return Page{}, fmt.Errorf("listAlbums: %w: unknown sort field %q", ErrValidation, opts.Sort)
+	}
+	after, err := decodeCursor(opts.Cursor, opts.Sort, opts.Descending)
+	if err != nil {
+		return Page{}, fmt.Errorf("listAlbums: %w", err)
+	}
+
+	albums := r.filter(func(album Album) bool {
+		return opts.matches(album) && (after == nil || afterCursor(album, after))
+	})
+	sort.Slice(albums, func(i, j int) bool {
+		if opts.Descending {
+			return compareBySort(albums[i], albums[j], opts.Sort) > 0
+		}
+		return compareBySort(albums[i], albums[j], opts.Sort) < 0
+	})
+
+	// Keep one album more than the limit, like the SQL repositories do, so newPage sees whether there is a next page.
+	if opts.Limit > 0 && len(albums) > opts.Limit+1 {
+		albums = albums[:opts.Limit+1]
+	}
+	return newPage(albums, opts), nil
+}
+
+// SearchAlbums implements Repository.
+func (r *memoryRepository) SearchAlbums(ctx context.Context, terms []string, limit int) ([]Album, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("searchAlbums %q: %w", terms, err)
+	}
+
+	return rankAlbums(r.filter(func(Album) bool { return true }), terms, limit), nil
+}
+
+// UpdateAlbum implements Repository.
+func (r *memoryRepository) UpdateAlbum(ctx context.Context, album Album) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("updateAlbum %d: %w", album.ID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.albums[album.ID]
+	if !ok || stored.DeletedAt != nil {
+		return fmt.Errorf("updateAlbum %d: %w", album.ID, ErrNotFound)
+	}
+	if album.Version == AnyVersion {
+		album.Version = stored.Version
+	}
+	if stored.Version != album.Version {
+		return fmt.Errorf("updateAlbum %d: %w", album.ID, versionMismatchError(album.Version, stored.Version))
+	}
+	album.Version++
+	album.CreatedAt, album.UpdatedAt, album.DeletedAt = stored.CreatedAt, timestamp(), nil
+	r.albums[album.ID] = album
+	r.record(ctx, ActionUpdate, &stored, &album)
+	return nil
+}
+
+// DeleteAlbum implements Repository.
+func (r *memoryRepository) DeleteAlbum(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("deleteAlbum %d: %w", id, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.albums[id]
+	if !ok || stored.DeletedAt != nil {
+		return fmt.Errorf("deleteAlbum %d: %w", id, ErrNotFound)
+	}
+	now := timestamp()
+	deleted := stored
+	deleted.Version, deleted.UpdatedAt, deleted.DeletedAt = stored.Version+1, now, &now
+	r.albums[id] = deleted
+	r.record(ctx, ActionDelete, &stored, &deleted)
+	return nil
+}
+
+// RestoreAlbum implements Repository.
+func (r *memoryRepository) RestoreAlbum(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("restoreAlbum %d: %w", id, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.albums[id]
+	if !ok {
+		return fmt.Errorf("restoreAlbum %d: %w", id, ErrNotFound)
+	}
+	if stored.DeletedAt == nil {
+		return fmt.Errorf("restoreAlbum %d: %w: the album isn't deleted", id, ErrConflict)
+	}
+	restored := stored
+	restored.Version, restored.UpdatedAt, restored.DeletedAt = stored.Version+1, timestamp(), nil
+	r.albums[id] = restored
+	r.record(ctx, ActionRestore, &stored, &restored)
+	return nil
+}
+
+// AuditLog implements Repository.
+func (r *memoryRepository) AuditLog(ctx context.Context, albumID int64) ([]AuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("auditLog %d: %w", albumID, err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []AuditEntry
+	for _, entry := range r.audit {
+		if entry.AlbumID == albumID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// record appends a change to the audit log, the caller holds the write lock.
+func (r *memoryRepository) record(ctx context.Context, action string, before, after *Album) {
+	entry := newAuditEntry(ctx, action, before, after)
+	entry.ID = int64(len(r.audit)) + 1
+	r.audit = append(r.audit, entry)
+}
+
+// WithTx implements Repository.
+// fn works on a copy of the albums which replaces the stored ones once fn succeeds, so a failing fn leaves no trace.
+// The repository stays locked until fn returns, the other callers wait for the transaction like they would for a lock.
+func (r *memoryRepository) WithTx(ctx context.Context, fn func(Repository) error) error {
+	if r.inTx {
+		return fn(r)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("withTx: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := &memoryRepository{albums: maps.Clone(r.albums), lastID: r.lastID, audit: slices.Clip(r.audit), inTx: true}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	r.albums, r.lastID, r.audit = tx.albums, tx.lastID, tx.audit
+	return nil
+}
+
+// filter returns the albums matching keep ordered by id, the same order the SQL repositories use.
+// The deleted albums are always left out.
+// Like them, it returns a nil slice when nothing matches.
+func (r *memoryRepository) filter(keep func(Album) bool) []Album {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var albums []Album
+	for _, album := range r.albums {
+		if album.DeletedAt == nil && keep(album) {
+			albums = append(albums, album)
+		}
+	}
+	sort.Slice(albums, func(i, j int) bool { return albums[i].ID < albums[j].ID })
+	return albums
+}
@@ -1,9 +1,49 @@
 package album
 
+import (
+	"time"
+
+	"example/money"
+)
+
 // Album represents the structure of an "album" entity.
 type Album struct {
-	ID     int64
-	Title  string
-	Artist string
-	Price  float32
+	ID     int64       `json:"id"`
+	Title  string      `json:"title"`
+	Artist string      `json:"artist"`
+	Price  money.Money `json:"price"`
+	// Version starts at 1 and is incremented by every update, it guards the updates against lost writes.
+	Version int64 `json:"version"`
+
+	// The timestamps are set by the repository, the values sent by a client are ignored.
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is set on a deleted album, which stays in the database until it is restored.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// firstVersion is the version of a newly added album, the default of the version column.
+const firstVersion = 1
+
+// AnyVersion is passed as the expected version of an update which should overwrite the album whatever its version.
+const AnyVersion = 0
+
+// AlbumPatch holds the fields of a partial update, nil fields are left untouched.
+type AlbumPatch struct {
+	Title  *string      `json:"title"`
+	Artist *string      `json:"artist"`
+	Price  *money.Money `json:"price"`
+}
+
+// Apply copies every non-nil field of the patch onto the album.
+func (p AlbumPatch) Apply(album *Album) {
+	if p.Title != nil {
+		album.Title = *p.Title
+	}
+	if p.Artist != nil {
+		album.Artist = *p.Artist
+	}
+	if p.Price != nil {
+		album.Price = *p.Price
+	}
 }
@@ -0,0 +1,193 @@
+package album
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"example/money"
+)
+
+// SortField is a column the album list can be ordered by.
+type SortField string
+
+const (
+	SortByID     SortField = "id"
+	SortByTitle  SortField = "title"
+	SortByArtist SortField = "artist"
+	SortByPrice  SortField = "price"
+)
+
+// The page size used when ListOptions.Limit is zero, and the largest one the service accepts.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ListOptions narrows down, orders and pages the album list.
+// The zero value lists every album ordered by id.
+type ListOptions struct {
+	// Limit is the maximum number of albums in the page, zero means no limit for the repository
+	// and DefaultPageSize for the service.
+	Limit int
+	// Cursor is the Page.NextCursor of the previous page, empty for the first page.
+	// It is only valid together with the Sort and Descending it was created with.
+	Cursor string
+
+	Sort       SortField
+	Descending bool
+
+	// Artist keeps only the albums of this exact artist.
+	Artist string
+	// TitleContains keeps only the albums whose title contains this text, ignoring case.
+	TitleContains string
+	// MinPrice and MaxPrice are inclusive bounds, they also keep only the albums in their currency.
+	MinPrice *money.Money
+	MaxPrice *money.Money
+}
+
+// sortField returns the sort field, defaulting to the id.
+func (o ListOptions) sortField() SortField {
+	if o.Sort == "" {
+		return SortByID
+	}
+	return o.Sort
+}
+
+// Page is one page of the album list.
+type Page struct {
+	Albums []Album `json:"albums"`
+	// NextCursor fetches the following page, it is empty on the last one.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// cursor is the position after which the next page starts: the sort value and the id of the last album of a page.
+// The id breaks ties between albums sharing the same sort value, which makes the order total.
+// Keyset pagination like this stays fast and stable on large tables, unlike OFFSET it doesn't skip or repeat
+// albums when rows are inserted or deleted between two pages.
+type cursor struct {
+	Sort       SortField `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	Value      string    `json:"v"`
+	ID         int64     `json:"id"`
+}
+
+// encode returns the opaque string handed to clients.
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads back a cursor and checks that it belongs to the given ordering.
+// A nil cursor is returned for an empty string.
+func decodeCursor(s string, sort SortField, descending bool) (*cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	verr := &ValidationError{}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	var c cursor
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil {
+		verr.add("cursor", RuleInvalid, "is not a cursor returned by a previous page")
+		return nil, verr
+	}
+	if c.Sort != sort || c.Descending != descending {
+		verr.add("cursor", RuleInvalid, "was created for a different sort order")
+		return nil, verr
+	}
+	return &c, nil
+}
+
+// matches reports whether album passes the filters of opts.
+func (o ListOptions) matches(album Album) bool {
+	if o.Artist != "" && album.Artist != o.Artist {
+		return false
+	}
+	if o.TitleContains != "" && !strings.Contains(strings.ToLower(album.Title), strings.ToLower(o.TitleContains)) {
+		return false
+	}
+	if o.MinPrice != nil && (album.Price.Currency != o.MinPrice.Currency || compareSortValues(SortByPrice, album.Price.Decimal(), o.MinPrice.Decimal()) < 0) {
+		return false
+	}
+	if o.MaxPrice != nil && (album.Price.Currency != o.MaxPrice.Currency || compareSortValues(SortByPrice, album.Price.Decimal(), o.MaxPrice.Decimal()) > 0) {
+		return false
+	}
+	return true
+}
+
+// sortValue returns the value of the sort field of an album, as stored in a cursor.
+func sortValue(album Album, sort SortField) string {
+	switch sort {
+	case SortByTitle:
+		return album.Title
+	case SortByArtist:
+		return album.Artist
+	case SortByPrice:
+		return album.Price.Decimal()
+	default:
+		return strconv.FormatInt(album.ID, 10)
+	}
+}
+
+// newPage turns the albums fetched for opts, with one album more than opts.Limit when there is a next page, into a Page.
+func newPage(albums []Album, opts ListOptions) Page {
+	if opts.Limit <= 0 || len(albums) <= opts.Limit {
+		return Page{Albums: albums}
+	}
+
+	albums = albums[:opts.Limit]
+	last := albums[len(albums)-1]
+	next := cursor{Sort: opts.Sort, Descending: opts.Descending, Value: sortValue(last, opts.Sort), ID: last.ID}
+	return Page{Albums: albums, NextCursor: next.encode()}
+}
+
+// compareBySort orders two albums the way the SQL repositories do: by the sort field, then by id.
+func compareBySort(a, b Album, sort SortField) int {
+	if c := compareSortValues(sort, sortValue(a, sort), sortValue(b, sort)); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// afterCursor reports whether album comes after the cursor in the requested order.
+func afterCursor(album Album, c *cursor) bool {
+	order := compareSortValues(c.Sort, sortValue(album, c.Sort), c.Value)
+	if order == 0 {
+		order = cmp.Compare(album.ID, c.ID)
+	}
+	if c.Descending {
+		return order < 0
+	}
+	return order > 0
+}
+
+// compareSortValues compares two values of the sort field.
+// Prices are compared as exact decimals, so amounts in currencies with a different number of decimals compare correctly.
+func compareSortValues(sort SortField, a, b string) int {
+	switch sort {
+	case SortByTitle, SortByArtist:
+		return strings.Compare(a, b)
+	case SortByPrice:
+		return decimal(a).Cmp(decimal(b))
+	default:
+		idA, _ := strconv.ParseInt(a, 10, 64)
+		idB, _ := strconv.ParseInt(b, 10, 64)
+		return cmp.Compare(idA, idB)
+	}
+}
+
+// decimal parses an exact decimal, anything unparsable (e.g. a tampered cursor) counts as zero.
+func decimal(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return new(big.Rat)
+	}
+	return r
+}
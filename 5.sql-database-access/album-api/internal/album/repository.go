@@ -1,92 +1,576 @@
 package album
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 )
 
 // Repository handles all the database interactions for albums.
 // We use an interface to allow for easy mocking in tests.
 type Repository interface {
-	AddAlbum(album Album) (int64, error)
-	AlbumByID(id int64) (*Album, error)
-	AlbumsByArtist(artistName string) ([]Album, error)
+	AddAlbum(ctx context.Context, album Album) (int64, error)
+	AlbumByID(ctx context.Context, id int64) (*Album, error)
+	AlbumsByArtist(ctx context.Context, artistName string) ([]Album, error)
+	ListAlbums(ctx context.Context, opts ListOptions) (Page, error)
+	// SearchAlbums returns at most limit albums matching every lower-case term, the most relevant first.
+	SearchAlbums(ctx context.Context, terms []string, limit int) ([]Album, error)
+	// UpdateAlbum only writes the album when the stored one still has album.Version, and increments the version.
+	// It fails with ErrVersionMismatch when the album was changed since that version was read.
+	// With AnyVersion the album is written whatever its version.
+	UpdateAlbum(ctx context.Context, album Album) error
+	// DeleteAlbum only marks the album as deleted, the lookups skip it from then on until it is restored.
+	DeleteAlbum(ctx context.Context, id int64) error
+	// RestoreAlbum brings back a deleted album, it fails with ErrConflict when the album isn't deleted.
+	RestoreAlbum(ctx context.Context, id int64) error
+	// AuditLog returns every recorded change of an album, deleted or not, oldest first.
+	// Every write above appends to it in the same transaction as the change itself.
+	AuditLog(ctx context.Context, albumID int64) ([]AuditEntry, error)
+
+	// WithTx runs fn with a Repository whose operations all happen in a single transaction.
+	// The transaction is committed when fn returns nil and rolled back when it returns an error or panics,
+	// the error of fn is returned as is. Calling WithTx on the Repository handed to fn joins the running transaction.
+	WithTx(ctx context.Context, fn func(Repository) error) error
 }
 
-// mySQLRepository implements the Repository interface for a MySQL database.
-type mySQLRepository struct {
+// Every method takes the context of the caller and hands it to the driver,
+// so a cancelled context (e.g. a disconnected client) or an expired deadline aborts the running query.
+
+// sqlRepository implements the Repository interface on top of database/sql.
+// The queries are plain SQL understood by both MySQL and SQLite (including the ? placeholders),
+// whatever differs between the two lives in the dialect.
+type sqlRepository struct {
 	db *sql.DB
+	// tx is only set on the repository handed to a WithTx callback.
+	tx      *sql.Tx
+	dialect dialect
+	stmts   *statementCache
+	logger  *slog.Logger
 }
 
-func NewRepository(db *sql.DB) Repository {
-	return &mySQLRepository{db: db}
+// NewMySQLRepository returns a Repository storing the albums in a MySQL database.
+// The returned value also implements io.Closer, closing it releases the prepared statements.
+func NewMySQLRepository(db *sql.DB, logger *slog.Logger) Repository {
+	return &sqlRepository{db: db, dialect: mysqlDialect{}, stmts: newStatementCache(db), logger: logger}
 }
 
-// AlbumByID Returns the album from the database with a given id.
-func (r *mySQLRepository) AlbumByID(id int64) (*Album, error) {
-	var album Album
+// NewSQLiteRepository returns a Repository storing the albums in a SQLite database.
+// The returned value also implements io.Closer, closing it releases the prepared statements.
+func NewSQLiteRepository(db *sql.DB, logger *slog.Logger) Repository {
+	return &sqlRepository{db: db, dialect: sqliteDialect{}, stmts: newStatementCache(db), logger: logger}
+}
+
+// Close releases the prepared statements, the *sql.DB stays open.
+func (r *sqlRepository) Close() error {
+	return r.stmts.close()
+}
+
+// conn returns what the queries run on: the transaction if there is one, the pool otherwise.
+func (r *sqlRepository) conn() dbtx {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// logQuery logs the duration of an operation, it is deferred at its start with a pointer to its error.
+// The errors are only attached, whoever gets them decides whether they deserve more than a debug record.
+func (r *sqlRepository) logQuery(ctx context.Context, op string, start time.Time, err *error) {
+	attrs := []slog.Attr{slog.String("op", op), slog.Duration("duration", time.Since(start))}
+	if r.tx != nil {
+		attrs = append(attrs, slog.Bool("in_tx", true))
+	}
+	if *err != nil {
+		attrs = append(attrs, slog.Any("error", *err))
+	}
+	r.logger.LogAttrs(ctx, slog.LevelDebug, "query", attrs...)
+}
+
+// WithTx implements Repository.
+func (r *sqlRepository) WithTx(ctx context.Context, fn func(Repository) error) (err error) {
+	if r.tx != nil {
+		return fn(r)
+	}
+	defer r.logQuery(ctx, "transaction", time.Now(), &err)
 
-	// Since we are only expecting a single row as a response, we use the QueryRow method
-	// QueryRow doesn't return an error and always returns a non-nil value.
-	row := r.db.QueryRow("SELECT * FROM album WHERE id = ?", id)
+	// Preparing a statement on the pool takes a connection, which a single connection SQLite pool doesn't have
+	// to spare once the transaction holds it, so the statements of the writes are prepared before it begins.
+	for _, query := range r.writeQueries() {
+		if _, err := r.stmts.get(ctx, query); err != nil {
+			return fmt.Errorf("withTx: %w", err)
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("withTx: %w", err)
+	}
+	// Rolling back a committed transaction does nothing, so this only matters when fn fails or panics.
+	defer tx.Rollback()
+
+	txRepo := &sqlRepository{db: r.db, tx: tx, dialect: r.dialect, stmts: r.stmts, logger: r.logger}
+	if err := fn(txRepo); err != nil {
+		return err
+	}
 
-	// QueryRow waits until the user uses the row.Scan method which will throw the error(if any)
-	// which was supposed to be returned by the QueryRow function.
-	if err := row.Scan(&album.ID, &album.Title, &album.Artist, &album.Price); err != nil {
-		// This error(if any) is returned by the QueryRow function.
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("withTx: commit: %w", err)
+	}
+	return nil
+}
+
+// writeQueries are the statements the writes and the service run in their transactions.
+// With SQLite lockRow leaves the queries alone, the cache holds them once.
+func (r *sqlRepository) writeQueries() []string {
+	return []string{
+		queryAlbumByID,
+		r.dialect.lockRow(queryAlbumByID),
+		r.dialect.lockRow(queryAnyAlbumByID),
+		r.dialect.lockRow(queryAlbumVersion),
+		queryInsertAlbum,
+		queryUpdateAlbum,
+		querySetDeletedAt,
+		queryInsertAudit,
+	}
+}
+
+// stmt returns the prepared statement for query from the cache of the repository.
+// In a transaction the cached statement is bound to its connection with StmtContext, database/sql closes that copy
+// when the transaction ends. A query missing from the cache is prepared on the transaction alone, see WithTx.
+func (r *sqlRepository) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	if r.tx == nil {
+		return r.stmts.get(ctx, query)
+	}
+	if stmt, ok := r.stmts.cached(query); ok {
+		return r.tx.StmtContext(ctx, stmt), nil
+	}
+	stmt, err := r.tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("preparing %q: %w", query, err)
+	}
+	return stmt, nil
+}
+
+// transact runs fn in a transaction, joining the running one if there is any.
+func (r *sqlRepository) transact(ctx context.Context, fn func(tx *sqlRepository) error) error {
+	return r.WithTx(ctx, func(tx Repository) error {
+		return fn(tx.(*sqlRepository))
+	})
+}
+
+// AlbumByID Returns the album from the database with a given id.
+func (r *sqlRepository) AlbumByID(ctx context.Context, id int64) (_ *Album, err error) {
+	defer r.logQuery(ctx, "albumByID", time.Now(), &err)
+	stmt, err := r.stmt(ctx, queryAlbumByID)
+	if err != nil {
+		return nil, fmt.Errorf("albumById %d: %w", id, err)
+	}
+
+	// Since we are only expecting a single row as a response, we use the QueryRowContext method
+	// QueryRowContext doesn't return an error and always returns a non-nil value.
+	// It waits until the row is scanned to return the error (if any) of the query.
+	album, err := scanAlbum(stmt.QueryRowContext(ctx, id))
+	if err != nil {
 		// Checked error for query returning zero rows.
-		if err == sql.ErrNoRows {
-			return &album, fmt.Errorf("albumById %d: no such album", id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("albumById %d: %w", id, ErrNotFound)
 		}
 		// Unchecked error
-		return &album, fmt.Errorf("albumById: %d: %v", id, err)
+		return nil, fmt.Errorf("albumById %d: %w", id, err)
 	}
 	return &album, nil
 }
 
 // AlbumsByArtist Returns all the albums with a given artist name
-func (r *mySQLRepository) AlbumsByArtist(artistName string) ([]Album, error) {
-	// Album slice to hold data from returned rows.
-	var albums []Album
+func (r *sqlRepository) AlbumsByArtist(ctx context.Context, artistName string) (_ []Album, err error) {
+	defer r.logQuery(ctx, "albumsByArtist", time.Now(), &err)
+	stmt, err := r.stmt(ctx, queryAlbumsByArtist)
+	if err != nil {
+		return nil, fmt.Errorf("albumsByArtist %q: %w", artistName, err)
+	}
 
 	// Run select query on DB to get albums with a specified artist.
-	rows, err := r.db.Query("SELECT * FROM album WHERE artist = ?", artistName)
+	rows, err := stmt.QueryContext(ctx, artistName)
 	if err != nil {
-		return nil, fmt.Errorf("albumsByArtist %q: %v", artistName, err)
+		return nil, fmt.Errorf("albumsByArtist %q: %w", artistName, err)
 	}
-	defer rows.Close()
 
-	// Loop through returned rows to convert data into the strongly typed object.
-	for rows.Next() {
-		var album Album
-		if rowScanErr := rows.Scan(&album.ID, &album.Title, &album.Artist, &album.Price); rowScanErr != nil {
-			return nil, fmt.Errorf("albumsByArtist %q: %v", artistName, rowScanErr)
+	albums, err := scanAlbums(rows)
+	if err != nil {
+		return nil, fmt.Errorf("albumsByArtist %q: %w", artistName, err)
+	}
+	return albums, nil
+}
+
+// AddAlbum Inserts a new album into the database.
+func (r *sqlRepository) AddAlbum(ctx context.Context, album Album) (_ int64, err error) {
+	defer r.logQuery(ctx, "addAlbum", time.Now(), &err)
+
+	var id int64
+	err = r.transact(ctx, func(tx *sqlRepository) error {
+		stmt, err := tx.stmt(ctx, queryInsertAlbum)
+		if err != nil {
+			return err
+		}
+
+		// ExecContext() is used to run queries which don't return any rows.
+		now := timestamp()
+		result, err := stmt.ExecContext(ctx, album.Title, album.Artist, album.Price.Currency, album.Price, now, now)
+		if err != nil {
+			return tx.dialect.classify(err)
+		}
+
+		// Get the ID of the insertion to return to the caller.
+		if id, err = result.LastInsertId(); err != nil {
+			return err
+		}
+
+		album.ID, album.Version, album.CreatedAt, album.UpdatedAt, album.DeletedAt = id, firstVersion, now, now, nil
+		return tx.audit(ctx, ActionCreate, nil, &album)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("addAlbum: %w", err)
+	}
+
+	return id, nil
+}
+
+// sortColumns maps every SortField to its column, it is also the whitelist keeping user input out of the ORDER BY clause.
+var sortColumns = map[SortField]string{
+	SortByID:     "id",
+	SortByTitle:  "title",
+	SortByArtist: "artist",
+	SortByPrice:  "price",
+}
+
+// ListAlbums Returns one page of the albums matching the filters of opts, in the requested order.
+func (r *sqlRepository) ListAlbums(ctx context.Context, opts ListOptions) (_ Page, err error) {
+	defer r.logQuery(ctx, "listAlbums", time.Now(), &err)
+	opts.Sort = opts.sortField()
+	column, ok := sortColumns[opts.Sort]
+	if !ok {
+		return Page{}, fmt.Errorf("listAlbums: %w: unknown sort field %q", ErrValidation, opts.Sort)
+	}
+	price := r.dialect.price()
+	if opts.Sort == SortByPrice {
+		column = price
+	}
+	after, err := decodeCursor(opts.Cursor, opts.Sort, opts.Descending)
+	if err != nil {
+		return Page{}, fmt.Errorf("listAlbums: %w", err)
+	}
+
+	// Build the WHERE clause from the filters, every value is passed as a placeholder argument.
+	var (
+		conditions = []string{notDeleted}
+		args       []any
+	)
+	if opts.Artist != "" {
+		conditions = append(conditions, "artist = ?")
+		args = append(args, opts.Artist)
+	}
+	if opts.TitleContains != "" {
+		conditions = append(conditions, "LOWER(title) LIKE ? ESCAPE '!'")
+		args = append(args, "%"+escapeLike(strings.ToLower(opts.TitleContains))+"%")
+	}
+	// Prices are compared as DECIMAL on both sides in MySQL, SQLite has no exact numbers to compare them as.
+	if opts.MinPrice != nil {
+		conditions = append(conditions, "currency = ? AND "+price+" >= CAST(? AS DECIMAL(19,4))")
+		args = append(args, opts.MinPrice.Currency, opts.MinPrice.Decimal())
+	}
+	if opts.MaxPrice != nil {
+		conditions = append(conditions, "currency = ? AND "+price+" <= CAST(? AS DECIMAL(19,4))")
+		args = append(args, opts.MaxPrice.Currency, opts.MaxPrice.Decimal())
+	}
+
+	// Keyset pagination: continue right after the (sort value, id) of the last album of the previous page.
+	direction, comparison := "ASC", ">"
+	if opts.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if after != nil {
+		switch opts.Sort {
+		case SortByID:
+			conditions = append(conditions, "id "+comparison+" ?")
+			args = append(args, after.ID)
+		case SortByPrice:
+			conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s CAST(? AS DECIMAL(19,4)) OR (%[1]s = CAST(? AS DECIMAL(19,4)) AND id %[2]s ?))", price, comparison))
+			args = append(args, after.Value, after.Value, after.ID)
+		default:
+			conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", column, comparison))
+			args = append(args, after.Value, after.Value, after.ID)
 		}
-		albums = append(albums, album)
 	}
 
-	// rows.Err returns an error (if any) indicating that the rows.Next() was terminated due to rows exhaustion or an error was occured.
-	// Important to check rows.Err() after looping through all rows
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("albumsByArtist: %q: %v", artistName, err)
+	query := "SELECT " + albumColumns + " FROM album WHERE " + strings.Join(conditions, " AND ")
+	query += fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s", column, direction)
+	if opts.Limit > 0 {
+		// One album more than asked tells whether there is a next page.
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+
+	// The query depends on the options, so it isn't worth keeping a prepared statement for it.
+	rows, err := r.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return Page{}, fmt.Errorf("listAlbums: %w", err)
+	}
+
+	albums, err := scanAlbums(rows)
+	if err != nil {
+		return Page{}, fmt.Errorf("listAlbums: %w", err)
+	}
+
+	return newPage(albums, opts), nil
+}
+
+// SearchAlbums Returns the albums whose title or artist has words starting with every term, ranked by relevance.
+func (r *sqlRepository) SearchAlbums(ctx context.Context, terms []string, limit int) (_ []Album, err error) {
+	defer r.logQuery(ctx, "searchAlbums", time.Now(), &err)
+	query, args, ranked := r.dialect.searchQuery(terms, limit)
+	rows, err := r.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("searchAlbums %q: %w", terms, err)
 	}
 
+	albums, err := scanAlbums(rows)
+	if err != nil {
+		return nil, fmt.Errorf("searchAlbums %q: %w", terms, err)
+	}
+	if !ranked {
+		albums = rankAlbums(albums, terms, limit)
+	}
 	return albums, nil
 }
 
-// AddAlbum Inserts a new album into the database.
-func (r *mySQLRepository) AddAlbum(album Album) (int64, error) {
-	// Exec() is used to run queries which don't return any rows.
-	result, err := r.db.Exec("INSERT INTO album (title, artist, price) VALUES (?, ?, ?)", album.Title, album.Artist, album.Price)
+// escapeLike Escapes the LIKE wildcards in s with "!", the ESCAPE character used by ListAlbums.
+// "!" works the same in MySQL and SQLite, unlike a backslash which MySQL also treats as a string escape.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// UpdateAlbum Replaces every column of the album having the same id and version as the given album,
+// whatever its version with AnyVersion. The row is locked when it is read, so no other write can happen
+// between the version check and the update.
+func (r *sqlRepository) UpdateAlbum(ctx context.Context, album Album) (err error) {
+	defer r.logQuery(ctx, "updateAlbum", time.Now(), &err)
+
+	err = r.transact(ctx, func(tx *sqlRepository) error {
+		// The album as it was, for the audit log.
+		before, err := tx.albumByID(ctx, tx.dialect.lockRow(queryAlbumByID), album.ID)
+		if err != nil {
+			return err
+		}
+		if album.Version == AnyVersion {
+			album.Version = before.Version
+		}
+		if before.Version != album.Version {
+			return versionMismatchError(album.Version, before.Version)
+		}
+
+		stmt, err := tx.stmt(ctx, queryUpdateAlbum)
+		if err != nil {
+			return err
+		}
+		now := timestamp()
+		result, err := stmt.ExecContext(ctx, album.Title, album.Artist, album.Price.Currency, album.Price, now, album.ID, album.Version)
+		if err != nil {
+			return tx.dialect.classify(err)
+		}
+		if updated, err := rowAffected(result); err != nil || !updated {
+			if err != nil {
+				return err
+			}
+			return tx.versionMismatch(ctx, album)
+		}
+
+		after := album
+		after.Version, after.CreatedAt, after.UpdatedAt, after.DeletedAt = album.Version+1, before.CreatedAt, now, nil
+		return tx.audit(ctx, ActionUpdate, before, &after)
+	})
 	if err != nil {
-		return 0, fmt.Errorf("addAlbum: %v", err)
+		return fmt.Errorf("updateAlbum %d: %w", album.ID, err)
 	}
+	return nil
+}
 
-	// Get the ID of the insertion to return to the caller.
-	id, err := result.LastInsertId()
+// versionMismatch Returns the error of an update which didn't match any row:
+// ErrNotFound when the album is gone, ErrVersionMismatch when it has another version.
+// The version is read with lockRow, which sees the latest commit and not the snapshot of the transaction.
+func (r *sqlRepository) versionMismatch(ctx context.Context, album Album) error {
+	stmt, err := r.stmt(ctx, r.dialect.lockRow(queryAlbumVersion))
 	if err != nil {
-		return 0, fmt.Errorf("addAlbum: %v", err)
+		return err
 	}
 
-	return id, nil
+	var current int64
+	if err := stmt.QueryRowContext(ctx, album.ID).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if current == album.Version {
+		return fmt.Errorf("%w: the album changed while it was being written", ErrVersionMismatch)
+	}
+	return versionMismatchError(album.Version, current)
+}
+
+// DeleteAlbum Marks the album with the given id as deleted, the row stays in the database.
+func (r *sqlRepository) DeleteAlbum(ctx context.Context, id int64) (err error) {
+	defer r.logQuery(ctx, "deleteAlbum", time.Now(), &err)
+
+	err = r.transact(ctx, func(tx *sqlRepository) error {
+		before, err := tx.albumByID(ctx, tx.dialect.lockRow(queryAlbumByID), id)
+		if err != nil {
+			return err
+		}
+
+		now := timestamp()
+		if err := tx.setDeletedAt(ctx, *before, &now, now); err != nil {
+			return err
+		}
+
+		after := *before
+		after.Version, after.UpdatedAt, after.DeletedAt = before.Version+1, now, &now
+		return tx.audit(ctx, ActionDelete, before, &after)
+	})
+	if err != nil {
+		return fmt.Errorf("deleteAlbum %d: %w", id, err)
+	}
+	return nil
+}
+
+// RestoreAlbum Clears the deletion mark of the album with the given id.
+func (r *sqlRepository) RestoreAlbum(ctx context.Context, id int64) (err error) {
+	defer r.logQuery(ctx, "restoreAlbum", time.Now(), &err)
+
+	err = r.transact(ctx, func(tx *sqlRepository) error {
+		before, err := tx.albumByID(ctx, tx.dialect.lockRow(queryAnyAlbumByID), id)
+		if err != nil {
+			return err
+		}
+		if before.DeletedAt == nil {
+			return fmt.Errorf("%w: the album isn't deleted", ErrConflict)
+		}
+
+		now := timestamp()
+		if err := tx.setDeletedAt(ctx, *before, nil, now); err != nil {
+			return err
+		}
+
+		after := *before
+		after.Version, after.UpdatedAt, after.DeletedAt = before.Version+1, now, nil
+		return tx.audit(ctx, ActionRestore, before, &after)
+	})
+	if err != nil {
+		return fmt.Errorf("restoreAlbum %d: %w", id, err)
+	}
+	return nil
+}
+
+// albumByID reads one album with query, either queryAlbumByID or queryAnyAlbumByID.
+func (r *sqlRepository) albumByID(ctx context.Context, query string, id int64) (*Album, error) {
+	stmt, err := r.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	album, err := scanAlbum(stmt.QueryRowContext(ctx, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &album, nil
+}
+
+// setDeletedAt writes the deletion mark of an album read in the same transaction,
+// failing with ErrVersionMismatch when a concurrent write changed it since.
+func (r *sqlRepository) setDeletedAt(ctx context.Context, album Album, deletedAt *time.Time, now time.Time) error {
+	stmt, err := r.stmt(ctx, querySetDeletedAt)
+	if err != nil {
+		return err
+	}
+	result, err := stmt.ExecContext(ctx, deletedAt, now, album.ID, album.Version)
+	if err != nil {
+		return err
+	}
+	updated, err := rowAffected(result)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return fmt.Errorf("%w: the album changed while it was being written", ErrVersionMismatch)
+	}
+	return nil
+}
+
+// rowAffected Reports whether the statement touched a row.
+// Every write bumps the version, so a matched row is always a changed row, whatever the MySQL clientFoundRows setting.
+func rowAffected(result sql.Result) (bool, error) {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// audit Appends a change of an album to the audit log, it has to run in the transaction of the change.
+func (r *sqlRepository) audit(ctx context.Context, action string, before, after *Album) error {
+	entry := newAuditEntry(ctx, action, before, after)
+	beforeData, err := marshalAuditAlbum(entry.Before)
+	if err != nil {
+		return err
+	}
+	afterData, err := marshalAuditAlbum(entry.After)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := r.stmt(ctx, queryInsertAudit)
+	if err != nil {
+		return err
+	}
+	if _, err := stmt.ExecContext(ctx, entry.AlbumID, entry.Action, entry.Actor, beforeData, afterData, entry.At); err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	return nil
+}
+
+// AuditLog Returns the recorded changes of the album with the given id, oldest first.
+func (r *sqlRepository) AuditLog(ctx context.Context, albumID int64) (_ []AuditEntry, err error) {
+	defer r.logQuery(ctx, "auditLog", time.Now(), &err)
+	rows, err := r.conn().QueryContext(ctx, queryAuditLog, albumID)
+	if err != nil {
+		return nil, fmt.Errorf("auditLog %d: %w", albumID, err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var (
+			entry                 AuditEntry
+			beforeData, afterData []byte
+		)
+		if err := rows.Scan(&entry.ID, &entry.AlbumID, &entry.Action, &entry.Actor, &beforeData, &afterData, &entry.At); err != nil {
+			return nil, fmt.Errorf("auditLog %d: %w", albumID, err)
+		}
+		entry.At = entry.At.UTC()
+		if entry.Before, err = unmarshalAuditAlbum(beforeData); err != nil {
+			return nil, fmt.Errorf("auditLog %d: entry %d: %w", albumID, entry.ID, err)
+		}
+		if entry.After, err = unmarshalAuditAlbum(afterData); err != nil {
+			return nil, fmt.Errorf("auditLog %d: entry %d: %w", albumID, entry.ID, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("auditLog %d: %w", albumID, err)
+	}
+	return entries, nil
 }
@@ -0,0 +1,627 @@
+package album
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+
+	"album-api/internal/database"
+	"example/money"
+)
+
+// testRepository is the conformance suite every Repository implementation has to pass.
+// newRepo must return an empty repository for every call.
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	t.Run("AddAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := t.Context()
+
+		want := Album{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")}
+		id, err := repo.AddAlbum(ctx, want)
+		if err != nil {
+			t.Fatalf("AddAlbum() error = %v", err)
+		}
+		if id <= 0 {
+			t.Fatalf("AddAlbum() id = %d; want a positive id", id)
+		}
+
+		got, err := repo.AlbumByID(ctx, id)
+		if err != nil {
+			t.Fatalf("AlbumByID(%d) error = %v", id, err)
+		}
+		if got.CreatedAt.IsZero() || !got.UpdatedAt.Equal(got.CreatedAt) || got.DeletedAt != nil {
+			t.Errorf("AlbumByID(%d) timestamps = %v, %v, %v; want equal creation and update times", id, got.CreatedAt, got.UpdatedAt, got.DeletedAt)
+		}
+		want.ID, want.Version, want.CreatedAt, want.UpdatedAt = id, 1, got.CreatedAt, got.UpdatedAt
+		if *got != want {
+			t.Errorf("AlbumByID(%d) = %+v; want %+v", id, *got, want)
+		}
+	})
+
+	t.Run("ExactPrices", func(t *testing.T) {
+		repo := newRepo(t)
+		// Neither fits the old DECIMAL(5,2) column nor survives a float32 round trip.
+		prices := []money.Money{
+			money.MustParse("399.31", "USD"),
+			money.MustParse("123456789.99", "EUR"),
+			money.MustParse("1500", "JPY"),
+			money.MustParse("1.234", "KWD"),
+			// The largest amounts validate accepts, past what a float64 holds exactly.
+			money.MustParse("999999999999999.99", "USD"),
+			money.MustParse("999999999999999", "JPY"),
+			money.MustParse("999999999999999.999", "KWD"),
+		}
+
+		for _, price := range prices {
+			id := mustAdd(t, repo, Album{Title: "Sajna", Artist: "Pujan Khunt", Price: price})
+			got, err := repo.AlbumByID(t.Context(), id)
+			if err != nil {
+				t.Fatalf("AlbumByID() error = %v", err)
+			}
+			if got.Price != price {
+				t.Errorf("stored price %v came back as %v", price, got.Price)
+			}
+		}
+	})
+
+	t.Run("IDsAreUnique", func(t *testing.T) {
+		repo := newRepo(t)
+		first := mustAdd(t, repo, Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+		second := mustAdd(t, repo, Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+		if first == second {
+			t.Errorf("AddAlbum() returned id %d twice", first)
+		}
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		repo := newRepo(t)
+		got, err := repo.AlbumByID(t.Context(), 42)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("AlbumByID(42) error = %v; want ErrNotFound", err)
+		}
+		if got != nil {
+			t.Errorf("AlbumByID(42) = %+v; want nil alongside the error", got)
+		}
+	})
+
+	t.Run("AlbumsByArtist", func(t *testing.T) {
+		repo := newRepo(t)
+		blueTrain := mustAdd(t, repo, Album{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")})
+		mustAdd(t, repo, Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+		giantSteps := mustAdd(t, repo, Album{Title: "Giant Steps", Artist: "John Coltrane", Price: usd("63.99")})
+
+		albums, err := repo.AlbumsByArtist(t.Context(), "John Coltrane")
+		if err != nil {
+			t.Fatalf("AlbumsByArtist() error = %v", err)
+		}
+		assertIDs(t, albums, blueTrain, giantSteps)
+
+		albums, err = repo.AlbumsByArtist(t.Context(), "Nobody")
+		if err != nil {
+			t.Fatalf("AlbumsByArtist() error = %v", err)
+		}
+		assertIDs(t, albums)
+	})
+
+	t.Run("ListAlbums", func(t *testing.T) {
+		repo := newRepo(t)
+		first := mustAdd(t, repo, Album{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")})
+		second := mustAdd(t, repo, Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+
+		page, err := repo.ListAlbums(t.Context(), ListOptions{})
+		if err != nil {
+			t.Fatalf("ListAlbums() error = %v", err)
+		}
+		assertIDs(t, page.Albums, first, second)
+		if page.NextCursor != "" {
+			t.Errorf("ListAlbums() without a limit returned cursor %q", page.NextCursor)
+		}
+	})
+
+	t.Run("ListAlbumsSortedAndPaged", func(t *testing.T) {
+		repo := newRepo(t)
+		cheap := mustAdd(t, repo, Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("9.50")})
+		pricey := mustAdd(t, repo, Album{Title: "Sajna", Artist: "Pujan Khunt", Price: usd("399.31")})
+		tieA := mustAdd(t, repo, Album{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")})
+		tieB := mustAdd(t, repo, Album{Title: "Giant Steps", Artist: "John Coltrane", Price: usd("56.99")})
+
+		tests := []struct {
+			name string
+			opts ListOptions
+			want []int64
+		}{
+			// 9.50 has to come before 56.99, which a text comparison would get wrong.
+			{"By price", ListOptions{Sort: SortByPrice}, []int64{cheap, tieA, tieB, pricey}},
+			{"By price descending", ListOptions{Sort: SortByPrice, Descending: true}, []int64{pricey, tieB, tieA, cheap}},
+			{"By title", ListOptions{Sort: SortByTitle}, []int64{tieA, tieB, cheap, pricey}},
+			{"By id descending", ListOptions{Descending: true}, []int64{tieB, tieA, pricey, cheap}},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				// Walk the pages two albums at a time, the ties on price have to be split across pages correctly.
+				var got []Album
+				opts := tc.opts
+				opts.Limit = 2
+				for range len(tc.want) {
+					page, err := repo.ListAlbums(t.Context(), opts)
+					if err != nil {
+						t.Fatalf("ListAlbums(%+v) error = %v", opts, err)
+					}
+					got = append(got, page.Albums...)
+					if page.NextCursor == "" {
+						break
+					}
+					opts.Cursor = page.NextCursor
+				}
+				assertIDs(t, got, tc.want...)
+			})
+		}
+	})
+
+	t.Run("ListAlbumsFiltered", func(t *testing.T) {
+		repo := newRepo(t)
+		blueTrain := mustAdd(t, repo, Album{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")})
+		giantSteps := mustAdd(t, repo, Album{Title: "Giant Steps", Artist: "John Coltrane", Price: usd("63.99")})
+		jeru := mustAdd(t, repo, Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+		jazz := mustAdd(t, repo, Album{Title: "100%_Jazz", Artist: "Various", Price: money.MustParse("20", "EUR")})
+		low, high := usd("17.99"), usd("60")
+
+		tests := []struct {
+			name string
+			opts ListOptions
+			want []int64
+		}{
+			{"Artist", ListOptions{Artist: "John Coltrane"}, []int64{blueTrain, giantSteps}},
+			{"Title ignoring case", ListOptions{TitleContains: "STEPS"}, []int64{giantSteps}},
+			{"Title with wildcards", ListOptions{TitleContains: "%_"}, []int64{jazz}},
+			{"Price range in USD", ListOptions{MinPrice: &low, MaxPrice: &high}, []int64{blueTrain, jeru}},
+			{"Everything", ListOptions{Artist: "John Coltrane", TitleContains: "train", MaxPrice: &high}, []int64{blueTrain}},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				page, err := repo.ListAlbums(t.Context(), tc.opts)
+				if err != nil {
+					t.Fatalf("ListAlbums(%+v) error = %v", tc.opts, err)
+				}
+				assertIDs(t, page.Albums, tc.want...)
+			})
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		id := mustAdd(t, repo, Album{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")})
+
+		want := Album{ID: id, Title: "Blue Train (Remastered)", Artist: "John Coltrane", Price: usd("59.99"), Version: 1}
+		if err := repo.UpdateAlbum(t.Context(), want); err != nil {
+			t.Fatalf("UpdateAlbum() error = %v", err)
+		}
+		// Writing the same values again still finds the row, and still bumps the version.
+		want.Version = 2
+		if err := repo.UpdateAlbum(t.Context(), want); err != nil {
+			t.Fatalf("UpdateAlbum() with unchanged values error = %v", err)
+		}
+		want.Version = 3
+
+		got, err := repo.AlbumByID(t.Context(), id)
+		if err != nil {
+			t.Fatalf("AlbumByID() error = %v", err)
+		}
+		if got.UpdatedAt.Before(got.CreatedAt) {
+			t.Errorf("AlbumByID() after update updated_at %v is before created_at %v", got.UpdatedAt, got.CreatedAt)
+		}
+		want.CreatedAt, want.UpdatedAt = got.CreatedAt, got.UpdatedAt
+		if *got != want {
+			t.Errorf("AlbumByID() after update = %+v; want %+v", *got, want)
+		}
+	})
+
+	t.Run("UpdateStaleVersion", func(t *testing.T) {
+		repo := newRepo(t)
+		album := Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99"), Version: 1}
+		album.ID = mustAdd(t, repo, album)
+
+		first, second := album, album
+		first.Price, second.Price = usd("19.99"), usd("21.99")
+		if err := repo.UpdateAlbum(t.Context(), first); err != nil {
+			t.Fatalf("first UpdateAlbum() error = %v", err)
+		}
+		if err := repo.UpdateAlbum(t.Context(), second); !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("UpdateAlbum() of version 1 after an update error = %v; want ErrVersionMismatch", err)
+		}
+
+		got, err := repo.AlbumByID(t.Context(), album.ID)
+		if err != nil {
+			t.Fatalf("AlbumByID() error = %v", err)
+		}
+		if got.Price != first.Price || got.Version != 2 {
+			t.Errorf("AlbumByID() after a rejected update = %+v; want the first update at version 2", *got)
+		}
+	})
+
+	t.Run("UpdateAnyVersion", func(t *testing.T) {
+		repo := newRepo(t)
+		album := Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99"), Version: 1}
+		album.ID = mustAdd(t, repo, album)
+		album.Price = usd("19.99")
+		if err := repo.UpdateAlbum(t.Context(), album); err != nil {
+			t.Fatal(err)
+		}
+
+		album.Version, album.Price = AnyVersion, usd("21.99")
+		if err := repo.UpdateAlbum(t.Context(), album); err != nil {
+			t.Fatalf("UpdateAlbum() with AnyVersion error = %v", err)
+		}
+		got, err := repo.AlbumByID(t.Context(), album.ID)
+		if err != nil || got.Price != album.Price || got.Version != 3 {
+			t.Errorf("AlbumByID() after an update with AnyVersion = %+v, %v; want the new price at version 3", got, err)
+		}
+
+		if err := repo.DeleteAlbum(t.Context(), album.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.UpdateAlbum(t.Context(), album); !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateAlbum() with AnyVersion of a deleted album error = %v; want ErrNotFound", err)
+		}
+	})
+
+	t.Run("UpdateMissing", func(t *testing.T) {
+		repo := newRepo(t)
+		err := repo.UpdateAlbum(t.Context(), Album{ID: 42, Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99"), Version: 1})
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateAlbum() error = %v; want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		id := mustAdd(t, repo, Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+
+		if err := repo.DeleteAlbum(t.Context(), id); err != nil {
+			t.Fatalf("DeleteAlbum() error = %v", err)
+		}
+		if _, err := repo.AlbumByID(t.Context(), id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("AlbumByID() after delete error = %v; want ErrNotFound", err)
+		}
+		if err := repo.DeleteAlbum(t.Context(), id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second DeleteAlbum() error = %v; want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := t.Context()
+		blueTrain := mustAdd(t, repo, Album{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")})
+		giantSteps := mustAdd(t, repo, Album{Title: "Giant Steps", Artist: "John Coltrane", Price: usd("63.99")})
+		mustAdd(t, repo, Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+
+		// The relevance differs between the engines, so only the set of albums is checked here.
+		tests := []struct {
+			terms []string
+			want  []int64
+		}{
+			{[]string{"coltrane"}, []int64{blueTrain, giantSteps}},
+			{[]string{"blue", "tra"}, []int64{blueTrain}},
+			{[]string{"giant", "coltrane"}, []int64{giantSteps}},
+			{[]string{"gian"}, []int64{giantSteps}},
+			{[]string{"rain"}, nil},
+			{[]string{"blue", "mulligan"}, nil},
+		}
+		for _, tc := range tests {
+			albums, err := repo.SearchAlbums(ctx, tc.terms, 10)
+			if err != nil {
+				t.Fatalf("SearchAlbums(%q) error = %v", tc.terms, err)
+			}
+			var ids []int64
+			for _, album := range albums {
+				ids = append(ids, album.ID)
+			}
+			slices.Sort(ids)
+			if !slices.Equal(ids, tc.want) {
+				t.Errorf("SearchAlbums(%q) = ids %v; want %v", tc.terms, ids, tc.want)
+			}
+		}
+
+		albums, err := repo.SearchAlbums(ctx, []string{"coltrane"}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(albums) != 1 {
+			t.Errorf("SearchAlbums() with limit 1 returned %d albums", len(albums))
+		}
+	})
+
+	t.Run("WithTxCommits", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := t.Context()
+		jeru := mustAdd(t, repo, Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+
+		var added int64
+		err := repo.WithTx(ctx, func(tx Repository) error {
+			var err error
+			if added, err = tx.AddAlbum(ctx, Album{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")}); err != nil {
+				return err
+			}
+			if err := tx.DeleteAlbum(ctx, jeru); err != nil {
+				return err
+			}
+			// The transaction sees its own writes.
+			_, err = tx.AlbumByID(ctx, added)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("WithTx() error = %v", err)
+		}
+
+		page, err := repo.ListAlbums(ctx, ListOptions{})
+		if err != nil {
+			t.Fatalf("ListAlbums() error = %v", err)
+		}
+		assertIDs(t, page.Albums, added)
+	})
+
+	t.Run("WithTxRollsBack", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := t.Context()
+		jeru := mustAdd(t, repo, Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+
+		errAbort := errors.New("abort")
+		err := repo.WithTx(ctx, func(tx Repository) error {
+			if _, err := tx.AddAlbum(ctx, Album{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")}); err != nil {
+				return err
+			}
+			// A nested WithTx joins the outer transaction, it is rolled back with it.
+			return tx.WithTx(ctx, func(tx Repository) error {
+				if err := tx.DeleteAlbum(ctx, jeru); err != nil {
+					return err
+				}
+				return errAbort
+			})
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("WithTx() error = %v; want the error of fn", err)
+		}
+
+		page, err := repo.ListAlbums(ctx, ListOptions{})
+		if err != nil {
+			t.Fatalf("ListAlbums() error = %v", err)
+		}
+		assertIDs(t, page.Albums, jeru)
+	})
+
+	t.Run("SoftDeleteAndRestore", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := WithActor(t.Context(), "alice")
+		id := mustAdd(t, repo, Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+
+		if err := repo.DeleteAlbum(ctx, id); err != nil {
+			t.Fatalf("DeleteAlbum() error = %v", err)
+		}
+		// A deleted album is hidden from every lookup.
+		if albums, err := repo.AlbumsByArtist(ctx, "Gerry Mulligan"); err != nil || len(albums) != 0 {
+			t.Errorf("AlbumsByArtist() after delete = %+v, %v; want no album", albums, err)
+		}
+		if page, err := repo.ListAlbums(ctx, ListOptions{}); err != nil || len(page.Albums) != 0 {
+			t.Errorf("ListAlbums() after delete = %+v, %v; want no album", page.Albums, err)
+		}
+		if albums, err := repo.SearchAlbums(ctx, []string{"jeru"}, 10); err != nil || len(albums) != 0 {
+			t.Errorf("SearchAlbums() after delete = %+v, %v; want no album", albums, err)
+		}
+		err := repo.UpdateAlbum(ctx, Album{ID: id, Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("19.99"), Version: 2})
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateAlbum() of a deleted album error = %v; want ErrNotFound", err)
+		}
+
+		if err := repo.RestoreAlbum(ctx, id); err != nil {
+			t.Fatalf("RestoreAlbum() error = %v", err)
+		}
+		got, err := repo.AlbumByID(ctx, id)
+		if err != nil {
+			t.Fatalf("AlbumByID() after restore error = %v", err)
+		}
+		if got.DeletedAt != nil || got.Version != 3 {
+			t.Errorf("AlbumByID() after restore = %+v; want a live album at version 3", *got)
+		}
+		if err := repo.RestoreAlbum(ctx, id); !errors.Is(err, ErrConflict) {
+			t.Errorf("RestoreAlbum() of a live album error = %v; want ErrConflict", err)
+		}
+		if err := repo.RestoreAlbum(ctx, id+1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("RestoreAlbum() of a missing album error = %v; want ErrNotFound", err)
+		}
+	})
+
+	t.Run("AuditLog", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := WithActor(t.Context(), "alice")
+		album := Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")}
+		id, err := repo.AddAlbum(t.Context(), album)
+		if err != nil {
+			t.Fatal(err)
+		}
+		album.ID, album.Version, album.Price = id, 1, usd("19.99")
+		if err := repo.UpdateAlbum(ctx, album); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.DeleteAlbum(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		// A failed transaction leaves no trace in the log either.
+		_ = repo.WithTx(ctx, func(tx Repository) error {
+			if err := tx.RestoreAlbum(ctx, id); err != nil {
+				return err
+			}
+			return errors.New("abort")
+		})
+
+		entries, err := repo.AuditLog(ctx, id)
+		if err != nil {
+			t.Fatalf("AuditLog() error = %v", err)
+		}
+		want := []struct {
+			action, actor           string
+			beforePrice, afterPrice string
+		}{
+			{ActionCreate, Anonymous, "", "17.99"},
+			{ActionUpdate, "alice", "17.99", "19.99"},
+			{ActionDelete, "alice", "19.99", "19.99"},
+		}
+		if len(entries) != len(want) {
+			t.Fatalf("AuditLog() = %d entries %+v; want %d", len(entries), entries, len(want))
+		}
+		for i, entry := range entries {
+			price := func(a *Album) string {
+				if a == nil {
+					return ""
+				}
+				return a.Price.Decimal()
+			}
+			if entry.AlbumID != id || entry.Action != want[i].action || entry.Actor != want[i].actor ||
+				price(entry.Before) != want[i].beforePrice || price(entry.After) != want[i].afterPrice {
+				t.Errorf("entry %d = %+v; want %+v", i, entry, want[i])
+			}
+			if entry.At.IsZero() || (i > 0 && entry.ID <= entries[i-1].ID) {
+				t.Errorf("entry %d has id %d and time %v; want increasing ids and a time", i, entry.ID, entry.At)
+			}
+		}
+		if after := entries[2].After; after == nil || after.DeletedAt == nil {
+			t.Errorf("delete entry after = %+v; want the deletion time", after)
+		}
+	})
+
+	t.Run("CancelledContext", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		if _, err := repo.AddAlbum(ctx, Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")}); !errors.Is(err, context.Canceled) {
+			t.Errorf("AddAlbum() with a cancelled context error = %v; want context.Canceled", err)
+		}
+	})
+}
+
+func mustAdd(t *testing.T, repo Repository, album Album) int64 {
+	t.Helper()
+	id, err := repo.AddAlbum(t.Context(), album)
+	if err != nil {
+		t.Fatalf("AddAlbum(%+v) error = %v", album, err)
+	}
+	return id
+}
+
+// assertIDs checks that albums holds exactly the albums with the given ids, in that order.
+func assertIDs(t *testing.T, albums []Album, ids ...int64) {
+	t.Helper()
+	if len(albums) != len(ids) {
+		t.Fatalf("got %d albums %+v; want ids %v", len(albums), albums, ids)
+	}
+	for i, album := range albums {
+		if album.ID != ids[i] {
+			t.Errorf("album %d has id %d; want %d", i, album.ID, ids[i])
+		}
+	}
+}
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(*testing.T) Repository {
+		return NewMemoryRepository()
+	})
+}
+
+func TestSQLiteRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		cfg := database.DefaultConfig()
+		cfg.Driver = database.DriverSQLite
+		cfg.Path = database.InMemory
+		return NewSQLiteRepository(openMigrated(t, cfg), testLogger(t))
+	})
+}
+
+func TestSQLRepositoryStatementCache(t *testing.T) {
+	cfg := database.DefaultConfig()
+	cfg.Driver = database.DriverSQLite
+	cfg.Path = database.InMemory
+	repo := NewSQLiteRepository(openMigrated(t, cfg), testLogger(t)).(*sqlRepository)
+	ctx := t.Context()
+
+	id := mustAdd(t, repo, Album{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")})
+	for range 2 {
+		if _, err := repo.AlbumByID(ctx, id); err != nil {
+			t.Fatalf("AlbumByID() error = %v", err)
+		}
+		if _, err := repo.AlbumsByArtist(ctx, "John Coltrane"); err != nil {
+			t.Fatalf("AlbumsByArtist() error = %v", err)
+		}
+	}
+	// The writes reuse the statements of the pool in their transactions instead of preparing their own.
+	album := Album{ID: id, Title: "Blue Train", Artist: "John Coltrane", Price: usd("59.99"), Version: AnyVersion}
+	for range 2 {
+		if err := repo.UpdateAlbum(ctx, album); err != nil {
+			t.Fatalf("UpdateAlbum() error = %v", err)
+		}
+		if err := repo.DeleteAlbum(ctx, id); err != nil {
+			t.Fatalf("DeleteAlbum() error = %v", err)
+		}
+		if err := repo.RestoreAlbum(ctx, id); err != nil {
+			t.Fatalf("RestoreAlbum() error = %v", err)
+		}
+	}
+	// Every statement prepared once: the ones of the writes and the artist lookup.
+	want := len(slices.Compact(slices.Sorted(slices.Values(repo.writeQueries())))) + 1
+	if got := len(repo.stmts.stmts); got != want {
+		t.Errorf("%d cached statements, want %d", got, want)
+	}
+
+	if err := repo.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	// The statements are prepared again when the repository is used after Close.
+	if _, err := repo.AlbumByID(ctx, id); err != nil {
+		t.Fatalf("AlbumByID() after Close error = %v", err)
+	}
+}
+
+// TestMySQLRepository runs against the server configured by the usual DB_* variables
+// and only when ALBUM_TEST_MYSQL=1, since it needs a running MySQL.
+// All the albums in that database are deleted!
+func TestMySQLRepository(t *testing.T) {
+	if os.Getenv("ALBUM_TEST_MYSQL") != "1" {
+		t.Skip("set ALBUM_TEST_MYSQL=1 to run against MySQL")
+	}
+	cfg, err := database.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Driver = database.DriverMySQL
+
+	testRepository(t, func(t *testing.T) Repository {
+		db := openMigrated(t, cfg)
+		if _, err := db.ExecContext(t.Context(), "DELETE FROM album"); err != nil {
+			t.Fatal(err)
+		}
+		return NewMySQLRepository(db, testLogger(t))
+	})
+}
+
+// openMigrated connects to the database and applies all the migrations, the connection is closed with the test.
+func openMigrated(t *testing.T, cfg database.Config) *sql.DB {
+	t.Helper()
+	db, err := database.NewConnection(t.Context(), cfg, testLogger(t))
+	if err != nil {
+		t.Fatalf("NewConnection() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db, cfg.Driver, testLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(t.Context()); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	return db
+}
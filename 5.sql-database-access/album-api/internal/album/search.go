@@ -0,0 +1,108 @@
+package album
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxSearchTerms bounds the number of words of a search, each one adds a condition to the query.
+const maxSearchTerms = 8
+
+// searchTerms splits a search into lower-case words, anything but letters and digits separates them.
+// Dropping the punctuation also keeps the MySQL boolean full-text operators (+ - * " ...) out of the query.
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// validateSearch checks the words and the page size of a search.
+func validateSearch(terms []string, limit int) error {
+	verr := &ValidationError{}
+	switch {
+	case len(terms) == 0:
+		verr.add("q", RuleRequired, "must contain at least one letter or digit")
+	case len(terms) > maxSearchTerms:
+		verr.add("q", RuleMax, "must not have more than %d words", maxSearchTerms)
+	}
+	switch {
+	case limit < 1:
+		verr.add("limit", RuleMin, "must be between 1 and %d", MaxPageSize)
+	case limit > MaxPageSize:
+		verr.add("limit", RuleMax, "must be between 1 and %d", MaxPageSize)
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// Scores of a single search term, per word of the title or artist it matches.
+const (
+	scoreWordMatch   = 2
+	scorePrefixMatch = 1
+	// titleWeight favours a match in the title over the same match in the artist name.
+	titleWeight = 1.5
+)
+
+// searchScore is the relevance of an album for the terms, the fallback of the repositories without a full-text index.
+// Every term has to match the start of a word of the title or the artist, ignoring case: a whole word scores more than a prefix,
+// a word of the title more than one of the artist. ok is false when some term matches nothing.
+func searchScore(album Album, terms []string) (score float64, ok bool) {
+	titleWords, artistWords := searchTerms(album.Title), searchTerms(album.Artist)
+	for _, term := range terms {
+		termScore := titleWeight*wordsScore(titleWords, term) + wordsScore(artistWords, term)
+		if termScore == 0 {
+			return 0, false
+		}
+		score += termScore
+	}
+	return score, true
+}
+
+// wordsScore returns the score of the best word matching term.
+func wordsScore(words []string, term string) float64 {
+	best := 0
+	for _, word := range words {
+		switch {
+		case word == term:
+			return scoreWordMatch
+		case strings.HasPrefix(word, term):
+			best = scorePrefixMatch
+		}
+	}
+	return float64(best)
+}
+
+// rankAlbums keeps the albums matching every term, ordered by decreasing relevance then by id,
+// and returns at most limit of them.
+func rankAlbums(albums []Album, terms []string, limit int) []Album {
+	type scored struct {
+		album Album
+		score float64
+	}
+	var matches []scored
+	for _, album := range albums {
+		if score, ok := searchScore(album, terms); ok {
+			matches = append(matches, scored{album, score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].album.ID < matches[j].album.ID
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	// Like the other lookups, a search without a match returns a nil slice.
+	var ranked []Album
+	for _, match := range matches {
+		ranked = append(ranked, match.album)
+	}
+	return ranked
+}
@@ -0,0 +1,88 @@
+package album
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Blue Train", []string{"blue", "train"}},
+		{"  +blue* -\"TRAIN\"  ", []string{"blue", "train"}},
+		{"Beyoncé 4", []string{"beyoncé", "4"}},
+		{"*** ---", nil},
+	}
+	for _, tc := range tests {
+		if got := searchTerms(tc.text); !slices.Equal(got, tc.want) {
+			t.Errorf("searchTerms(%q) = %q; want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestRankAlbums(t *testing.T) {
+	albums := []Album{
+		{ID: 1, Title: "Coltrane Jazz", Artist: "John Coltrane"},
+		{ID: 2, Title: "Blue Train", Artist: "John Coltrane"},
+		{ID: 3, Title: "Blues for Coltrane", Artist: "McCoy Tyner"},
+		{ID: 4, Title: "Bluesology", Artist: "Milt Jackson"},
+		{ID: 5, Title: "Kind of Blue", Artist: "Miles Davis"},
+	}
+
+	tests := []struct {
+		name  string
+		terms []string
+		limit int
+		want  []int64
+	}{
+		// A whole word beats a prefix, the title beats the artist and ties are ordered by id.
+		{"Word before prefix", []string{"blue"}, 0, []int64{2, 5, 3, 4}},
+		{"Title before artist", []string{"coltrane"}, 0, []int64{1, 3, 2}},
+		{"Every term must match", []string{"blue", "coltrane"}, 0, []int64{2, 3}},
+		{"Limit", []string{"blue"}, 2, []int64{2, 5}},
+		{"No match", []string{"rain"}, 0, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var ids []int64
+			for _, album := range rankAlbums(albums, tc.terms, tc.limit) {
+				ids = append(ids, album.ID)
+			}
+			if !slices.Equal(ids, tc.want) {
+				t.Errorf("rankAlbums(%q) = ids %v; want %v", tc.terms, ids, tc.want)
+			}
+		})
+	}
+}
+
+func TestSearchAlbums_Validation(t *testing.T) {
+	service := NewService(NewMemoryRepository(), testLogger(t))
+	tests := []struct {
+		name      string
+		text      string
+		limit     int
+		wantField string
+		wantRule  string
+	}{
+		{"Empty text", "", 0, "q", RuleRequired},
+		{"Only punctuation", "*!?", 0, "q", RuleRequired},
+		{"Too many words", "a b c d e f g h i", 0, "q", RuleMax},
+		{"Limit too large", "blue", MaxPageSize + 1, "limit", RuleMax},
+		{"Negative limit", "blue", -1, "limit", RuleMin},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := service.SearchAlbums(t.Context(), tc.text, tc.limit)
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("SearchAlbums(%q, %d) error = %v; want a *ValidationError", tc.text, tc.limit, err)
+			}
+			if len(verr.Fields) != 1 || verr.Fields[0].Field != tc.wantField || verr.Fields[0].Rule != tc.wantRule {
+				t.Errorf("SearchAlbums(%q, %d) fields = %+v; want one %s error on %q", tc.text, tc.limit, verr.Fields, tc.wantRule, tc.wantField)
+			}
+		})
+	}
+}
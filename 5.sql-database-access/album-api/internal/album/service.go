@@ -1,38 +1,221 @@
 package album
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
 
 // Service provides the business logic for the album operations.
 type Service interface {
-	CreateAlbum(album Album) (int64, error)
-	GetAlbum(id int64) (*Album, error)
-	GetAlbumsByArtist(artistName string) ([]Album, error)
+	CreateAlbum(ctx context.Context, album Album) (int64, error)
+	GetAlbum(ctx context.Context, id int64) (*Album, error)
+	ListAlbums(ctx context.Context, opts ListOptions) (Page, error)
+	SearchAlbums(ctx context.Context, text string, limit int) ([]Album, error)
+	UpdateAlbum(ctx context.Context, album Album) (*Album, error)
+	PatchAlbum(ctx context.Context, id int64, version int64, patch AlbumPatch) (*Album, error)
+	DeleteAlbum(ctx context.Context, id int64) error
+	RestoreAlbum(ctx context.Context, id int64) (*Album, error)
+	AlbumHistory(ctx context.Context, id int64) ([]AuditEntry, error)
+	ImportAlbums(ctx context.Context, albums []Album) ([]ImportResult, error)
+}
+
+// ImportResult is the outcome of one album of an import: its new id, or the reason it was rejected.
+type ImportResult struct {
+	ID  int64
+	Err error
 }
 
 // Implicitly implements the Service interface, by implementing all methods defined in the interface.
 type albumService struct {
-	repo Repository
+	repo   Repository
+	logger *slog.Logger
 }
 
-func NewService(repo Repository) Service {
-	return &albumService{repo: repo}
+func NewService(repo Repository, logger *slog.Logger) Service {
+	return &albumService{repo: repo, logger: logger}
+}
+
+// withTx runs fn with a service whose repository operations all happen in one transaction,
+// which lets a method compose several of them atomically.
+func (a *albumService) withTx(ctx context.Context, fn func(tx *albumService) error) error {
+	return a.repo.WithTx(ctx, func(repo Repository) error {
+		return fn(&albumService{repo: repo, logger: a.logger})
+	})
 }
 
 // CreateAlbum implements Service.
-func (a *albumService) CreateAlbum(album Album) (int64, error) {
-	if album.Price < 0 {
-		return 0, fmt.Errorf("price of album must be positive")
+func (a *albumService) CreateAlbum(ctx context.Context, album Album) (int64, error) {
+	if err := validate(album); err != nil {
+		return 0, err
 	}
 
-	return a.repo.AddAlbum(album)
+	id, err := a.repo.AddAlbum(ctx, album)
+	if err != nil {
+		return 0, err
+	}
+	a.logger.InfoContext(ctx, "album created", slog.Int64("album_id", id), slog.String("actor", ActorFrom(ctx)))
+	return id, nil
 }
 
 // GetAlbum implements Service.
-func (a *albumService) GetAlbum(id int64) (*Album, error) {
-	return a.repo.AlbumByID(id)
+func (a *albumService) GetAlbum(ctx context.Context, id int64) (*Album, error) {
+	return a.repo.AlbumByID(ctx, id)
+}
+
+// ListAlbums implements Service.
+// A zero Limit is replaced by DefaultPageSize, so a single call never loads the whole catalog.
+func (a *albumService) ListAlbums(ctx context.Context, opts ListOptions) (Page, error) {
+	if opts.Limit == 0 {
+		opts.Limit = DefaultPageSize
+	}
+	if err := validateListOptions(opts); err != nil {
+		return Page{}, err
+	}
+
+	return a.repo.ListAlbums(ctx, opts)
 }
 
-// GetAlbumsByArtist implements Service.
-func (a *albumService) GetAlbumsByArtist(artistName string) ([]Album, error) {
-	return a.repo.AlbumsByArtist(artistName)
+// SearchAlbums implements Service.
+// The text is split into words, an album matches when its title or artist has a word starting with each of them, ignoring case.
+// The most relevant albums come first, a zero limit is replaced by DefaultPageSize.
+func (a *albumService) SearchAlbums(ctx context.Context, text string, limit int) ([]Album, error) {
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	terms := searchTerms(text)
+	if err := validateSearch(terms, limit); err != nil {
+		return nil, err
+	}
+
+	return a.repo.SearchAlbums(ctx, terms, limit)
+}
+
+// UpdateAlbum implements Service.
+// album.Version is the version the client based its change on, the update fails with ErrVersionMismatch when the album
+// has changed since. With AnyVersion the album is overwritten whatever its version.
+// The updated album is returned as stored, with its new version and timestamps.
+func (a *albumService) UpdateAlbum(ctx context.Context, album Album) (*Album, error) {
+	if err := validate(album); err != nil {
+		return nil, err
+	}
+
+	var updated *Album
+	err := a.withTx(ctx, func(tx *albumService) error {
+		// The repository resolves AnyVersion while it holds the row, a version read here could be stale by then.
+		if err := tx.repo.UpdateAlbum(ctx, album); err != nil {
+			return err
+		}
+		var err error
+		updated, err = tx.repo.AlbumByID(ctx, album.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.logger.InfoContext(ctx, "album updated", slog.Int64("album_id", updated.ID), slog.Int64("version", updated.Version), slog.String("actor", ActorFrom(ctx)))
+	return updated, nil
+}
+
+// PatchAlbum implements Service.
+// The stored album is read, the patch is applied on top of it and the result is validated and written back as a whole,
+// all in one transaction. version works like the Version of UpdateAlbum: the patch is only applied to that version of the album,
+// and the write is conditional on the version read, so a concurrent update is never overwritten.
+func (a *albumService) PatchAlbum(ctx context.Context, id int64, version int64, patch AlbumPatch) (*Album, error) {
+	var album *Album
+	err := a.withTx(ctx, func(tx *albumService) error {
+		var err error
+		album, err = tx.repo.AlbumByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if version != AnyVersion && album.Version != version {
+			return fmt.Errorf("patchAlbum %d: %w", id, versionMismatchError(version, album.Version))
+		}
+
+		patch.Apply(album)
+		if err := validate(*album); err != nil {
+			return err
+		}
+		if err := tx.repo.UpdateAlbum(ctx, *album); err != nil {
+			return err
+		}
+		album, err = tx.repo.AlbumByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.logger.InfoContext(ctx, "album patched", slog.Int64("album_id", id), slog.Int64("version", album.Version), slog.String("actor", ActorFrom(ctx)))
+	return album, nil
+}
+
+// DeleteAlbum implements Service.
+// The album is only marked as deleted, RestoreAlbum brings it back.
+func (a *albumService) DeleteAlbum(ctx context.Context, id int64) error {
+	if err := a.repo.DeleteAlbum(ctx, id); err != nil {
+		return err
+	}
+	a.logger.InfoContext(ctx, "album deleted", slog.Int64("album_id", id), slog.String("actor", ActorFrom(ctx)))
+	return nil
+}
+
+// RestoreAlbum implements Service.
+func (a *albumService) RestoreAlbum(ctx context.Context, id int64) (*Album, error) {
+	var restored *Album
+	err := a.withTx(ctx, func(tx *albumService) error {
+		if err := tx.repo.RestoreAlbum(ctx, id); err != nil {
+			return err
+		}
+		var err error
+		restored, err = tx.repo.AlbumByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.logger.InfoContext(ctx, "album restored", slog.Int64("album_id", id), slog.String("actor", ActorFrom(ctx)))
+	return restored, nil
+}
+
+// AlbumHistory implements Service.
+// An album without any entry is looked up, so a missing album fails with ErrNotFound instead of having an empty history.
+func (a *albumService) AlbumHistory(ctx context.Context, id int64) ([]AuditEntry, error) {
+	entries, err := a.repo.AuditLog(ctx, id)
+	if err != nil || len(entries) > 0 {
+		return entries, err
+	}
+	if _, err := a.repo.AlbumByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ImportAlbums implements Service.
+// Every album is validated on its own, the valid ones are added in a single transaction and the invalid ones are skipped,
+// the results are in the order of albums. The returned error is reserved for storage failures,
+// in which case the transaction is rolled back and none of the albums is added.
+func (a *albumService) ImportAlbums(ctx context.Context, albums []Album) ([]ImportResult, error) {
+	results := make([]ImportResult, len(albums))
+	imported := 0
+	err := a.withTx(ctx, func(tx *albumService) error {
+		for i, album := range albums {
+			if err := validate(album); err != nil {
+				results[i].Err = err
+				continue
+			}
+			id, err := tx.repo.AddAlbum(ctx, album)
+			if err != nil {
+				return err
+			}
+			results[i].ID = id
+			imported++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.logger.InfoContext(ctx, "albums imported", slog.Int("imported", imported), slog.Int("rejected", len(albums)-imported), slog.String("actor", ActorFrom(ctx)))
+	return results, nil
 }
@@ -0,0 +1,253 @@
+package album
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"example/money"
+)
+
+func usd(amount string) money.Money {
+	return money.MustParse(amount, "USD")
+}
+
+// testLogger writes every record to the test output, it is only shown for failed tests or with -v.
+func testLogger(t *testing.T) *slog.Logger {
+	return slog.New(slog.NewTextHandler(t.Output(), &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestCreateAlbum(t *testing.T) {
+	tests := []struct {
+		name    string
+		album   Album
+		wantErr error
+	}{
+		{"Valid album", Album{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")}, nil},
+		{"Free album", Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("0")}, nil},
+		{"Negative price", Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("-1")}, ErrValidation},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := NewMemoryRepository()
+			service := NewService(repo, testLogger(t))
+
+			id, err := service.CreateAlbum(t.Context(), tc.album)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("CreateAlbum() error = %v; want %v", err, tc.wantErr)
+			}
+
+			page, _ := repo.ListAlbums(t.Context(), ListOptions{})
+			albums := page.Albums
+			if tc.wantErr != nil {
+				if len(albums) != 0 {
+					t.Errorf("invalid album was stored: %+v", albums)
+				}
+				return
+			}
+			if len(albums) != 1 || albums[0].ID != id {
+				t.Errorf("stored albums = %+v; want just the album with id %d", albums, id)
+			}
+		})
+	}
+}
+
+func TestCreateAlbum_RejectsUnknownCurrency(t *testing.T) {
+	service := NewService(NewMemoryRepository(), testLogger(t))
+	_, err := service.CreateAlbum(t.Context(), Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: money.New(1799, "")})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("CreateAlbum() without a currency error = %v; want ErrValidation", err)
+	}
+}
+
+func TestUpdateAlbum_RejectsNegativePrice(t *testing.T) {
+	service := NewService(NewMemoryRepository(), testLogger(t))
+	id, err := service.CreateAlbum(t.Context(), Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = service.UpdateAlbum(t.Context(), Album{ID: id, Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("-5")})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("UpdateAlbum() error = %v; want ErrValidation", err)
+	}
+}
+
+func TestPatchAlbum(t *testing.T) {
+	service := NewService(NewMemoryRepository(), testLogger(t))
+	id, err := service.CreateAlbum(t.Context(), Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	price := usd("19.99")
+	got, err := service.PatchAlbum(t.Context(), id, AnyVersion, AlbumPatch{Price: &price})
+	if err != nil {
+		t.Fatalf("PatchAlbum() error = %v", err)
+	}
+
+	want := Album{ID: id, Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("19.99"), Version: 2, CreatedAt: got.CreatedAt, UpdatedAt: got.UpdatedAt}
+	if *got != want {
+		t.Errorf("PatchAlbum() = %+v; want %+v", *got, want)
+	}
+	stored, _ := service.GetAlbum(t.Context(), id)
+	if *stored != want {
+		t.Errorf("stored album = %+v; want %+v", *stored, want)
+	}
+
+	negative := usd("-1")
+	if _, err := service.PatchAlbum(t.Context(), id, AnyVersion, AlbumPatch{Price: &negative}); !errors.Is(err, ErrValidation) {
+		t.Errorf("PatchAlbum() with a negative price error = %v; want ErrValidation", err)
+	}
+	if _, err := service.PatchAlbum(t.Context(), id+1, AnyVersion, AlbumPatch{Price: &price}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("PatchAlbum() of a missing album error = %v; want ErrNotFound", err)
+	}
+}
+
+func TestUpdateAlbum_Versions(t *testing.T) {
+	service := NewService(NewMemoryRepository(), testLogger(t))
+	album := Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")}
+	id, err := service.CreateAlbum(t.Context(), album)
+	if err != nil {
+		t.Fatal(err)
+	}
+	album.ID = id
+
+	// Two clients read version 1, the first one to write wins.
+	album.Version = 1
+	album.Price = usd("19.99")
+	updated, err := service.UpdateAlbum(t.Context(), album)
+	if err != nil {
+		t.Fatalf("UpdateAlbum() error = %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("UpdateAlbum() version = %d; want 2", updated.Version)
+	}
+
+	album.Price = usd("21.99")
+	if _, err := service.UpdateAlbum(t.Context(), album); !errors.Is(err, ErrVersionMismatch) || !errors.Is(err, ErrConflict) {
+		t.Errorf("UpdateAlbum() of a stale version error = %v; want ErrVersionMismatch", err)
+	}
+	price := usd("23.99")
+	if _, err := service.PatchAlbum(t.Context(), id, 1, AlbumPatch{Price: &price}); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("PatchAlbum() of a stale version error = %v; want ErrVersionMismatch", err)
+	}
+	stored, _ := service.GetAlbum(t.Context(), id)
+	if stored.Price != usd("19.99") {
+		t.Errorf("stored price = %v; want the one of the first update", stored.Price)
+	}
+
+	// AnyVersion overwrites whatever is stored.
+	album.Version = AnyVersion
+	updated, err = service.UpdateAlbum(t.Context(), album)
+	if err != nil {
+		t.Fatalf("UpdateAlbum() with AnyVersion error = %v", err)
+	}
+	if updated.Version != 3 {
+		t.Errorf("UpdateAlbum() with AnyVersion version = %d; want 3", updated.Version)
+	}
+}
+
+func TestListAlbums_Validation(t *testing.T) {
+	service := NewService(NewMemoryRepository(), testLogger(t))
+	eur := money.MustParse("10", "EUR")
+	low, high := usd("10"), usd("20")
+
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		wantField string
+		wantRule  string
+	}{
+		{"Limit too large", ListOptions{Limit: MaxPageSize + 1}, "limit", RuleMax},
+		{"Negative limit", ListOptions{Limit: -1}, "limit", RuleMin},
+		{"Unknown sort", ListOptions{Sort: "year"}, "sort", RuleInvalid},
+		{"Garbage cursor", ListOptions{Cursor: "not-a-cursor"}, "cursor", RuleInvalid},
+		{"Cursor of another order", ListOptions{Cursor: cursor{Sort: SortByPrice, Value: "1", ID: 1}.encode()}, "cursor", RuleInvalid},
+		{"Mixed currencies", ListOptions{MinPrice: &low, MaxPrice: &eur}, "max_price", RuleCurrency},
+		{"Min above max", ListOptions{MinPrice: &high, MaxPrice: &low}, "max_price", RuleMin},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := service.ListAlbums(t.Context(), tc.opts)
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("ListAlbums() error = %v; want a *ValidationError", err)
+			}
+			if len(verr.Fields) != 1 || verr.Fields[0].Field != tc.wantField || verr.Fields[0].Rule != tc.wantRule {
+				t.Errorf("ListAlbums() fields = %+v; want one %s error on %q", verr.Fields, tc.wantRule, tc.wantField)
+			}
+		})
+	}
+}
+
+func TestListAlbums_DefaultPageSize(t *testing.T) {
+	repo := NewMemoryRepository()
+	for range DefaultPageSize + 1 {
+		mustAdd(t, repo, Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+	}
+
+	page, err := NewService(repo, testLogger(t)).ListAlbums(t.Context(), ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Albums) != DefaultPageSize || page.NextCursor == "" {
+		t.Errorf("ListAlbums() returned %d albums and cursor %q; want %d and a cursor", len(page.Albums), page.NextCursor, DefaultPageSize)
+	}
+}
+
+func TestImportAlbums(t *testing.T) {
+	repo := NewMemoryRepository()
+	service := NewService(repo, testLogger(t))
+
+	results, err := service.ImportAlbums(t.Context(), []Album{
+		{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")},
+		{Title: "", Artist: "John Coltrane", Price: usd("63.99")},
+		{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")},
+	})
+	if err != nil {
+		t.Fatalf("ImportAlbums() error = %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("ImportAlbums() returned %d results; want 3", len(results))
+	}
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Errorf("valid albums rejected: %+v", results)
+	}
+	if !errors.Is(results[1].Err, ErrValidation) || results[1].ID != 0 {
+		t.Errorf("album without a title got %+v; want a validation error", results[1])
+	}
+
+	page, err := repo.ListAlbums(t.Context(), ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIDs(t, page.Albums, results[0].ID, results[2].ID)
+}
+
+// noAuditRepository has no audit entries, like the albums created before migration 0006.
+type noAuditRepository struct {
+	Repository
+}
+
+func (noAuditRepository) AuditLog(context.Context, int64) ([]AuditEntry, error) {
+	return nil, nil
+}
+
+func TestAlbumHistory_WithoutEntries(t *testing.T) {
+	service := NewService(noAuditRepository{NewMemoryRepository()}, testLogger(t))
+	id, err := service.CreateAlbum(t.Context(), Album{Title: "Jeru", Artist: "Gerry Mulligan", Price: usd("17.99")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, err := service.AlbumHistory(t.Context(), id); err != nil || len(entries) != 0 {
+		t.Errorf("AlbumHistory() of an album without entries = %+v, %v; want no entry", entries, err)
+	}
+	if _, err := service.AlbumHistory(t.Context(), id+1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("AlbumHistory() of a missing album error = %v; want ErrNotFound", err)
+	}
+}
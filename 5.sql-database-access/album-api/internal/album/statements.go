@@ -0,0 +1,142 @@
+package album
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// albumColumns is the column list of every SELECT on the album table, in the order scanAlbum expects them.
+// Naming the columns instead of SELECT * keeps the scans working when a migration adds or reorders columns.
+const albumColumns = "id, title, artist, currency, price, version, created_at, updated_at, deleted_at"
+
+// notDeleted is the condition hiding the soft-deleted albums, every read of the album table but the ones of a restore has it.
+const notDeleted = "deleted_at IS NULL"
+
+// The queries run on every request. They are prepared once per repository and reused,
+// so the database parses and plans them only once instead of on every call.
+const (
+	queryAlbumByID      = "SELECT " + albumColumns + " FROM album WHERE id = ? AND " + notDeleted
+	queryAnyAlbumByID   = "SELECT " + albumColumns + " FROM album WHERE id = ?"
+	queryAlbumsByArtist = "SELECT " + albumColumns + " FROM album WHERE artist = ? AND " + notDeleted + " ORDER BY id"
+	queryInsertAlbum    = "INSERT INTO album (title, artist, currency, price, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)"
+	queryAlbumVersion   = "SELECT version FROM album WHERE id = ? AND " + notDeleted
+	queryUpdateAlbum    = "UPDATE album SET title = ?, artist = ?, currency = ?, price = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ? AND " + notDeleted
+	// querySetDeletedAt deletes an album with a time and restores it with NULL.
+	querySetDeletedAt = "UPDATE album SET deleted_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?"
+
+	queryInsertAudit = "INSERT INTO album_audit (album_id, action, actor, before_data, after_data, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	queryAuditLog    = "SELECT id, album_id, action, actor, before_data, after_data, created_at FROM album_audit WHERE album_id = ? ORDER BY id"
+)
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanAlbum reads one row selected with albumColumns.
+func scanAlbum(row rowScanner) (Album, error) {
+	var (
+		album     Album
+		deletedAt sql.NullTime
+	)
+	// The currency decides the number of minor units of the price, so it has to be scanned first.
+	err := row.Scan(&album.ID, &album.Title, &album.Artist, &album.Price.Currency, &album.Price, &album.Version,
+		&album.CreatedAt, &album.UpdatedAt, &deletedAt)
+	if err != nil {
+		return album, err
+	}
+
+	// The drivers don't agree on the location of the times they read, the repositories hand out UTC.
+	album.CreatedAt, album.UpdatedAt = album.CreatedAt.UTC(), album.UpdatedAt.UTC()
+	if deletedAt.Valid {
+		at := deletedAt.Time.UTC()
+		album.DeletedAt = &at
+	}
+	return album, nil
+}
+
+// scanAlbums reads every row of rows and closes it.
+func scanAlbums(rows *sql.Rows) ([]Album, error) {
+	defer rows.Close()
+
+	// Album slice to hold data from returned rows.
+	var albums []Album
+
+	// Loop through returned rows to convert data into the strongly typed object.
+	for rows.Next() {
+		album, err := scanAlbum(rows)
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, album)
+	}
+
+	// rows.Err returns an error (if any) indicating that the rows.Next() was terminated due to rows exhaustion or an error was occured.
+	// Important to check rows.Err() after looping through all rows
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return albums, nil
+}
+
+// dbtx is the part of the API shared by *sql.DB and *sql.Tx the repository needs,
+// the same queries run on the pool or inside a transaction.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// statementCache prepares every query the first time it is used and keeps the statement for the next calls.
+// A *sql.Stmt is safe for concurrent use, database/sql re-prepares it on other connections of the pool as needed.
+type statementCache struct {
+	conn  dbtx
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+func newStatementCache(conn dbtx) *statementCache {
+	return &statementCache{conn: conn, stmts: make(map[string]*sql.Stmt)}
+}
+
+// cached returns the statement already prepared for query, without preparing it.
+func (c *statementCache) cached(query string) (*sql.Stmt, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stmt, ok := c.stmts[query]
+	return stmt, ok
+}
+
+// get returns the prepared statement for query, preparing it on the first call.
+func (c *statementCache) get(ctx context.Context, query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := c.conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("preparing %q: %w", query, err)
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// close closes every prepared statement.
+func (c *statementCache) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var firstErr error
+	for query, stmt := range c.stmts {
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(c.stmts, query)
+	}
+	return firstErr
+}
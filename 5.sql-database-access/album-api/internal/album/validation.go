@@ -0,0 +1,145 @@
+package album
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"example/money"
+)
+
+// The limits below mirror the columns of the album table, see the migrations.
+const (
+	maxTitleLength  = 128 // title VARCHAR(128)
+	maxArtistLength = 255 // artist VARCHAR(255)
+	// maxPriceDigits is the number of integer digits that fit into price DECIMAL(19,4).
+	maxPriceDigits = 15
+)
+
+// The rules a FieldError can report.
+const (
+	RuleRequired = "required"
+	RuleMaxLen   = "max_length"
+	RuleMin      = "min"
+	RuleMax      = "max"
+	RuleCurrency = "currency"
+	RuleInvalid  = "invalid"
+)
+
+// FieldError describes a single rule broken by one field of an album.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every rule an album breaks, not just the first one,
+// so a client can fix all of them at once. It matches ErrValidation with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + ": " + field.Message
+	}
+	return fmt.Sprintf("%v: %s", ErrValidation, strings.Join(messages, "; "))
+}
+
+// Is makes errors.Is(err, ErrValidation) true for every ValidationError.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// add records a broken rule.
+func (e *ValidationError) add(field, rule, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// validate checks the business rules every stored album has to follow.
+// It returns a *ValidationError listing all the broken rules, or nil.
+func validate(album Album) error {
+	verr := &ValidationError{}
+
+	validateText(verr, "title", album.Title, maxTitleLength)
+	validateText(verr, "artist", album.Artist, maxArtistLength)
+
+	if err := album.Price.Validate(); err != nil {
+		verr.add("price.currency", RuleCurrency, "must be an ISO 4217 code like %q", money.DefaultCurrency)
+	} else {
+		if album.Price.IsNegative() {
+			verr.add("price.amount", RuleMin, "must not be negative")
+		}
+		if limit := priceLimit(album.Price.Currency); album.Price.Amount >= limit || album.Price.Amount <= -limit {
+			verr.add("price.amount", RuleMax, "must have at most %d digits before the decimal point", maxPriceDigits)
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// priceLimit returns the smallest amount, in minor units of the currency, that doesn't fit the price column anymore.
+func priceLimit(currency string) int64 {
+	limit := int64(1)
+	for range maxPriceDigits + money.Exponent(currency) {
+		limit *= 10
+	}
+	return limit
+}
+
+// validateText checks a required text field against the length of its VARCHAR column, which counts characters and not bytes.
+func validateText(verr *ValidationError, field, value string, maxLength int) {
+	switch {
+	case strings.TrimSpace(value) == "":
+		verr.add(field, RuleRequired, "must not be empty")
+	case utf8.RuneCountInString(value) > maxLength:
+		verr.add(field, RuleMaxLen, "must be at most %d characters long", maxLength)
+	}
+}
+
+// validateListOptions checks the paging, sorting and filtering options of a list request.
+func validateListOptions(opts ListOptions) error {
+	verr := &ValidationError{}
+
+	switch {
+	case opts.Limit < 1:
+		verr.add("limit", RuleMin, "must be between 1 and %d", MaxPageSize)
+	case opts.Limit > MaxPageSize:
+		verr.add("limit", RuleMax, "must be between 1 and %d", MaxPageSize)
+	}
+	if _, ok := sortColumns[opts.sortField()]; !ok {
+		verr.add("sort", RuleInvalid, "must be one of id, title, artist or price")
+	} else if _, err := decodeCursor(opts.Cursor, opts.sortField(), opts.Descending); err != nil {
+		var cursorErr *ValidationError
+		if errors.As(err, &cursorErr) {
+			verr.Fields = append(verr.Fields, cursorErr.Fields...)
+		}
+	}
+
+	for _, bound := range []struct {
+		field string
+		price *money.Money
+	}{{"min_price", opts.MinPrice}, {"max_price", opts.MaxPrice}} {
+		if bound.price != nil && bound.price.Validate() != nil {
+			verr.add(bound.field, RuleCurrency, "must be in an ISO 4217 currency like %q", money.DefaultCurrency)
+		}
+	}
+	if opts.MinPrice != nil && opts.MaxPrice != nil {
+		switch {
+		case opts.MinPrice.Currency != opts.MaxPrice.Currency:
+			verr.add("max_price", RuleCurrency, "must be in the same currency as min_price")
+		case compareSortValues(SortByPrice, opts.MinPrice.Decimal(), opts.MaxPrice.Decimal()) > 0:
+			verr.add("max_price", RuleMin, "must not be below min_price")
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
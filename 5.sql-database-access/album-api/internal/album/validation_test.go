@@ -0,0 +1,72 @@
+package album
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"example/money"
+)
+
+func TestValidate(t *testing.T) {
+	valid := Album{Title: "Blue Train", Artist: "John Coltrane", Price: usd("56.99")}
+
+	tests := []struct {
+		name   string
+		modify func(*Album)
+		want   []FieldError
+	}{
+		{"Valid album", func(*Album) {}, nil},
+		{"Title at the limit", func(a *Album) { a.Title = strings.Repeat("é", maxTitleLength) }, nil},
+		{"Empty title", func(a *Album) { a.Title = "  " }, []FieldError{
+			{Field: "title", Rule: RuleRequired, Message: "must not be empty"},
+		}},
+		{"Title too long", func(a *Album) { a.Title = strings.Repeat("a", maxTitleLength+1) }, []FieldError{
+			{Field: "title", Rule: RuleMaxLen, Message: "must be at most 128 characters long"},
+		}},
+		{"Artist too long", func(a *Album) { a.Artist = strings.Repeat("a", maxArtistLength+1) }, []FieldError{
+			{Field: "artist", Rule: RuleMaxLen, Message: "must be at most 255 characters long"},
+		}},
+		{"Missing currency", func(a *Album) { a.Price = money.New(100, "") }, []FieldError{
+			{Field: "price.currency", Rule: RuleCurrency, Message: `must be an ISO 4217 code like "USD"`},
+		}},
+		{"Negative price", func(a *Album) { a.Price = usd("-0.01") }, []FieldError{
+			{Field: "price.amount", Rule: RuleMin, Message: "must not be negative"},
+		}},
+		{"Price too large", func(a *Album) { a.Price = usd("1000000000000000") }, []FieldError{
+			{Field: "price.amount", Rule: RuleMax, Message: "must have at most 15 digits before the decimal point"},
+		}},
+		{"Every field at once", func(a *Album) { *a = Album{Price: usd("-1")} }, []FieldError{
+			{Field: "title", Rule: RuleRequired, Message: "must not be empty"},
+			{Field: "artist", Rule: RuleRequired, Message: "must not be empty"},
+			{Field: "price.amount", Rule: RuleMin, Message: "must not be negative"},
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			album := valid
+			tc.modify(&album)
+
+			err := validate(album)
+			if tc.want == nil {
+				if err != nil {
+					t.Errorf("validate() error = %v; want nil", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("validate() error = %v; want it to match ErrValidation", err)
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("validate() error = %T; want *ValidationError", err)
+			}
+			if !reflect.DeepEqual(verr.Fields, tc.want) {
+				t.Errorf("validate() fields = %+v; want %+v", verr.Fields, tc.want)
+			}
+		})
+	}
+}
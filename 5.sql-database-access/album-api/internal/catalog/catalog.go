@@ -0,0 +1,137 @@
+package catalog
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"album-api/internal/album"
+)
+
+// DefaultBatchSize is the number of albums added per transaction when ImportOptions.BatchSize is zero.
+const DefaultBatchSize = 500
+
+// ImportOptions tunes an import.
+type ImportOptions struct {
+	// BatchSize is the number of albums handed to the service at once, each batch is added in its own transaction.
+	BatchSize int
+}
+
+// RowError is a rejected row of an imported catalog.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// ImportReport sums up an import: how many albums were added and why the other rows were rejected.
+type ImportReport struct {
+	Imported int
+	Rejected []RowError
+}
+
+// Import reads a catalog and adds its albums through the service, which validates every album.
+// A row that can't be parsed or fails the validation is reported and skipped, the import goes on with the next one.
+// Only a broken file or a storage failure stops the import, the batches added before it stay in the database
+// and the report tells how many albums they held.
+func Import(ctx context.Context, service album.Service, r io.Reader, format Format, opts ImportOptions) (report ImportReport, err error) {
+	// The parse errors are reported right away and the validation errors once their batch is done, put them back in file order.
+	defer func() {
+		slices.SortStableFunc(report.Rejected, func(a, b RowError) int { return cmp.Compare(a.Line, b.Line) })
+	}()
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	reader, err := newReader(r, format)
+	if err != nil {
+		return report, err
+	}
+
+	// lines and albums are the pending batch, lines[i] is where albums[i] was read.
+	var (
+		lines  []int
+		albums []album.Album
+	)
+	flush := func() error {
+		if len(albums) == 0 {
+			return nil
+		}
+		results, err := service.ImportAlbums(ctx, albums)
+		if err != nil {
+			return fmt.Errorf("importing the albums of lines %d-%d: %w", lines[0], lines[len(lines)-1], err)
+		}
+		for i, result := range results {
+			if result.Err != nil {
+				report.Rejected = append(report.Rejected, RowError{Line: lines[i], Err: result.Err})
+				continue
+			}
+			report.Imported++
+		}
+		lines, albums = lines[:0], albums[:0]
+		return nil
+	}
+
+	for {
+		rec, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("reading catalog: %w", err)
+		}
+		if rec.Err != nil {
+			report.Rejected = append(report.Rejected, RowError{Line: rec.Line, Err: rec.Err})
+			continue
+		}
+
+		lines, albums = append(lines, rec.Line), append(albums, rec.Album)
+		if len(albums) == batchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	return report, flush()
+}
+
+// Export writes every album to w, ordered by id, and returns how many were written.
+// The albums are read page by page, so the whole catalog is never held in memory.
+func Export(ctx context.Context, service album.Service, w io.Writer, format Format) (int, error) {
+	writer, err := newWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	opts := album.ListOptions{Limit: album.MaxPageSize}
+	for {
+		page, err := service.ListAlbums(ctx, opts)
+		if err != nil {
+			return count, fmt.Errorf("listing albums: %w", err)
+		}
+		for _, a := range page.Albums {
+			if err := writer.Write(a); err != nil {
+				return count, fmt.Errorf("writing album %d: %w", a.ID, err)
+			}
+			count++
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	return count, writer.Flush()
+}
@@ -0,0 +1,190 @@
+package catalog
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"album-api/internal/album"
+	"example/money"
+)
+
+// testLogger writes every record to the test output, it is only shown for failed tests or with -v.
+func testLogger(t *testing.T) *slog.Logger {
+	return slog.New(slog.NewTextHandler(t.Output(), &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       Format
+		input        string
+		wantImported int
+		wantLines    []int
+	}{
+		{
+			name:   "CSV",
+			format: CSV,
+			input: `title,artist,price,currency
+Blue Train,John Coltrane,56.99,USD
+Giant Steps,John Coltrane,63.99,
+,Gerry Mulligan,17.99,USD
+Jeru,Gerry Mulligan,seventeen,USD
+Sarah Vaughan,Sarah Vaughan
+Kind of Blue,Miles Davis,1500,JPY
+`,
+			wantImported: 3,
+			wantLines:    []int{4, 5, 6},
+		},
+		{
+			name:   "CSV columns in any order",
+			format: CSV,
+			input: `price,id,artist,title
+56.99,7,John Coltrane,Blue Train
+`,
+			wantImported: 1,
+		},
+		{
+			name:   "JSON Lines",
+			format: JSONL,
+			input: `{"id": 7, "title": "Blue Train", "artist": "John Coltrane", "price": {"amount": "56.99", "currency": "USD"}}
+
+{"title": "Giant Steps", "artist": "John Coltrane", "price": 63.99}
+{"title": "Jeru", "artist": "Gerry Mulligan", "price": -1}
+{"title": "Sarah Vaughan",
+`,
+			wantImported: 2,
+			wantLines:    []int{4, 5},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			service := album.NewService(album.NewMemoryRepository(), testLogger(t))
+
+			report, err := Import(t.Context(), service, strings.NewReader(tc.input), tc.format, ImportOptions{BatchSize: 2})
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+			if report.Imported != tc.wantImported {
+				t.Errorf("Import() imported %d albums; want %d", report.Imported, tc.wantImported)
+			}
+
+			var lines []int
+			for _, rejected := range report.Rejected {
+				lines = append(lines, rejected.Line)
+			}
+			if !slices.Equal(lines, tc.wantLines) {
+				t.Errorf("Import() rejected lines %v (%v); want %v", lines, report.Rejected, tc.wantLines)
+			}
+
+			page, err := service.ListAlbums(t.Context(), album.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Albums) != tc.wantImported {
+				t.Errorf("%d albums stored; want %d", len(page.Albums), tc.wantImported)
+			}
+		})
+	}
+}
+
+func TestImport_ReportsValidationFields(t *testing.T) {
+	service := album.NewService(album.NewMemoryRepository(), testLogger(t))
+	input := "title,artist,price\n,John Coltrane,56.99\n"
+
+	report, err := Import(t.Context(), service, strings.NewReader(input), CSV, ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if len(report.Rejected) != 1 {
+		t.Fatalf("Import() rejected %v; want one row", report.Rejected)
+	}
+	var verr *album.ValidationError
+	if !errors.As(report.Rejected[0], &verr) || verr.Fields[0].Field != "title" {
+		t.Errorf("rejected row error = %v; want a validation error on the title", report.Rejected[0])
+	}
+}
+
+func TestImport_InvalidHeader(t *testing.T) {
+	for _, header := range []string{"", "title,artist\n", "title,artist,price,year\n", "title,title,artist,price\n"} {
+		service := album.NewService(album.NewMemoryRepository(), testLogger(t))
+		if _, err := Import(t.Context(), service, strings.NewReader(header), CSV, ImportOptions{}); err == nil {
+			t.Errorf("Import() with header %q succeeded; want an error", header)
+		}
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	albums := []album.Album{
+		{Title: "Blue Train", Artist: "John Coltrane", Price: money.MustParse("56.99", "USD")},
+		{Title: "Giant Steps, Remastered", Artist: "John \"Trane\" Coltrane", Price: money.MustParse("63.99", "EUR")},
+		{Title: "Kind of Blue", Artist: "Miles Davis", Price: money.MustParse("1500", "JPY")},
+	}
+
+	for _, format := range []Format{CSV, JSONL} {
+		t.Run(string(format), func(t *testing.T) {
+			source := album.NewService(album.NewMemoryRepository(), testLogger(t))
+			// More albums than a page, so the export has to follow the cursors.
+			for i := range album.MaxPageSize + 1 {
+				if _, err := source.CreateAlbum(t.Context(), albums[i%len(albums)]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var buf bytes.Buffer
+			exported, err := Export(t.Context(), source, &buf, format)
+			if err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			if exported != album.MaxPageSize+1 {
+				t.Errorf("Export() wrote %d albums; want %d", exported, album.MaxPageSize+1)
+			}
+
+			target := album.NewService(album.NewMemoryRepository(), testLogger(t))
+			report, err := Import(t.Context(), target, &buf, format, ImportOptions{})
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+			if report.Imported != exported || len(report.Rejected) != 0 {
+				t.Fatalf("Import() = %+v; want all %d albums imported", report, exported)
+			}
+
+			page, err := target.ListAlbums(t.Context(), album.ListOptions{Limit: len(albums)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, got := range page.Albums {
+				want := albums[i]
+				// The target database starts its own ids, versions and timestamps.
+				want.ID, want.Version, want.CreatedAt, want.UpdatedAt = got.ID, 1, got.CreatedAt, got.UpdatedAt
+				if got != want {
+					t.Errorf("imported album %d = %+v; want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    Format
+		wantErr bool
+	}{
+		{"albums.csv", CSV, false},
+		{"dump/albums.JSONL", JSONL, false},
+		{"albums.ndjson", JSONL, false},
+		{"albums.xml", "", true},
+		{"albums", "", true},
+	}
+	for _, tc := range tests {
+		got, err := FormatFromPath(tc.path)
+		if got != tc.want || (err != nil) != tc.wantErr {
+			t.Errorf("FormatFromPath(%q) = %q, %v; want %q, error %v", tc.path, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Package catalog moves albums in and out of the service as CSV or JSON Lines files.
+package catalog
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Format is a file format of an album catalog.
+type Format string
+
+const (
+	// CSV has a header row naming the columns: id, title, artist, price and currency.
+	CSV Format = "csv"
+	// JSONL has one album per line, written like the API does, e.g.
+	// {"id":1,"title":"Blue Train","artist":"John Coltrane","price":{"amount":"56.99","currency":"USD"}}
+	JSONL Format = "jsonl"
+)
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case CSV, JSONL:
+		return f, nil
+	case "ndjson":
+		return JSONL, nil
+	default:
+		return "", fmt.Errorf("unknown catalog format %q, want csv or jsonl", name)
+	}
+}
+
+// FormatFromPath guesses the format from the extension of a file name.
+func FormatFromPath(path string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return "", fmt.Errorf("can't tell the catalog format of %q without an extension", path)
+	}
+	return ParseFormat(ext)
+}
@@ -0,0 +1,145 @@
+package catalog
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"album-api/internal/album"
+	"example/money"
+)
+
+// record is one album read from a catalog along with the line it starts at.
+// Err is set when the line couldn't be turned into an album, the other lines can still be read.
+type record struct {
+	Line  int
+	Album album.Album
+	Err   error
+}
+
+// recordReader reads a catalog one album at a time, it returns io.EOF after the last one.
+// Any other error means the rest of the file can't be read.
+type recordReader interface {
+	Read() (record, error)
+}
+
+func newReader(r io.Reader, format Format) (recordReader, error) {
+	switch format {
+	case CSV:
+		return newCSVReader(r)
+	case JSONL:
+		return &jsonlReader{scanner: newLineScanner(r)}, nil
+	default:
+		return nil, fmt.Errorf("unknown catalog format %q", format)
+	}
+}
+
+// csvColumns are the columns of a CSV catalog, in the order they are exported.
+var csvColumns = []string{"id", "title", "artist", "price", "currency"}
+
+type csvReader struct {
+	reader *csv.Reader
+	// columns maps a column name to its index in the rows.
+	columns map[string]int
+}
+
+// newCSVReader reads the header row. The columns can come in any order, only title, artist and price are required.
+// The id column is ignored since the database assigns new ids, and a missing currency means USD.
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("csv catalog is empty, it needs at least a header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(csvColumns, name) {
+			return nil, fmt.Errorf("unknown csv column %q, want some of %s", name, strings.Join(csvColumns, ", "))
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("csv column %q appears twice", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"title", "artist", "price"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv header is missing the %q column", name)
+		}
+	}
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvReader) Read() (record, error) {
+	row, err := c.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+			// The row is complete, it just has the wrong number of fields.
+			return record{Line: parseErr.StartLine, Err: fmt.Errorf("has %d fields, the header has %d", len(row), len(c.columns))}, nil
+		}
+		return record{}, err
+	}
+	line, _ := c.reader.FieldPos(0)
+
+	currency := money.DefaultCurrency
+	if i, ok := c.columns["currency"]; ok && row[i] != "" {
+		currency = strings.ToUpper(row[i])
+	}
+	price, err := money.Parse(row[c.columns["price"]], currency)
+	if err != nil {
+		return record{Line: line, Err: fmt.Errorf("price: %w", err)}, nil
+	}
+
+	return record{Line: line, Album: album.Album{
+		Title:  row[c.columns["title"]],
+		Artist: row[c.columns["artist"]],
+		Price:  price,
+	}}, nil
+}
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// newLineScanner returns a scanner for lines of up to 1 MiB, well above the largest valid album.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return scanner
+}
+
+// Read skips the blank lines, the id and the version of the albums are ignored like in a CSV catalog.
+func (j *jsonlReader) Read() (record, error) {
+	for j.scanner.Scan() {
+		j.line++
+		line := strings.TrimSpace(j.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var a album.Album
+		if err := json.Unmarshal([]byte(line), &a); err != nil {
+			return record{Line: j.line, Err: fmt.Errorf("invalid JSON: %w", err)}, nil
+		}
+		a.ID, a.Version = 0, 0
+		return record{Line: j.line, Album: a}, nil
+	}
+	if err := j.scanner.Err(); err != nil {
+		return record{}, err
+	}
+	return record{}, io.EOF
+}
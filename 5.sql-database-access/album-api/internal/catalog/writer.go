@@ -0,0 +1,58 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"album-api/internal/album"
+)
+
+// recordWriter writes a catalog one album at a time, Flush has to be called after the last one.
+type recordWriter interface {
+	Write(a album.Album) error
+	Flush() error
+}
+
+func newWriter(w io.Writer, format Format) (recordWriter, error) {
+	switch format {
+	case CSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvColumns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{writer: writer}, nil
+	case JSONL:
+		return &jsonlWriter{encoder: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown catalog format %q", format)
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvWriter) Write(a album.Album) error {
+	return c.writer.Write([]string{strconv.FormatInt(a.ID, 10), a.Title, a.Artist, a.Price.Decimal(), a.Price.Currency})
+}
+
+func (c *csvWriter) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+// Write relies on the encoder ending every value with a newline.
+func (j *jsonlWriter) Write(a album.Album) error {
+	return j.encoder.Encode(a)
+}
+
+func (j *jsonlWriter) Flush() error {
+	return nil
+}
@@ -0,0 +1,220 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds everything needed to open and tune the connection pool.
+// It is built from DefaultConfig, then the optional JSON file named by DB_CONFIG_FILE,
+// then the DB_* environment variables, each step overriding the previous one.
+type Config struct {
+	// Driver selects the database engine: "mysql" or "sqlite".
+	Driver string `json:"driver"`
+
+	// Path is the SQLite database file, or ":memory:" for a throwaway in-memory database. Only used by the sqlite driver.
+	Path string `json:"path"`
+
+	// The MySQL server and credentials, only used by the mysql driver.
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Name     string `json:"name"`
+
+	// TLS is handed to the driver as is: "false", "true", "skip-verify" or "preferred".
+	TLS string `json:"tls"`
+
+	// Connection pool settings, see the sql.DB Set* methods.
+	MaxOpenConns    int      `json:"maxOpenConns"`
+	MaxIdleConns    int      `json:"maxIdleConns"`
+	ConnMaxLifetime Duration `json:"connMaxLifetime"`
+	ConnMaxIdleTime Duration `json:"connMaxIdleTime"`
+
+	// DialTimeout bounds a single attempt to reach the server.
+	DialTimeout Duration `json:"dialTimeout"`
+	// ConnectRetries is how many more times the startup ping is tried after the first failure.
+	// The wait between attempts starts at ConnectBackoff and doubles up to MaxConnectBackoff.
+	ConnectRetries    int      `json:"connectRetries"`
+	ConnectBackoff    Duration `json:"connectBackoff"`
+	MaxConnectBackoff Duration `json:"maxConnectBackoff"`
+
+	// AutoMigrate applies the pending migrations when the server starts.
+	AutoMigrate bool `json:"autoMigrate"`
+}
+
+// DefaultConfig returns the settings used for everything that isn't configured explicitly.
+func DefaultConfig() Config {
+	return Config{
+		Driver:            DriverMySQL,
+		Path:              "albums.db",
+		Host:              "127.0.0.1",
+		Port:              3306,
+		Name:              "mysql",
+		TLS:               "false",
+		MaxOpenConns:      25,
+		MaxIdleConns:      25,
+		ConnMaxLifetime:   Duration(5 * time.Minute),
+		ConnMaxIdleTime:   Duration(time.Minute),
+		DialTimeout:       Duration(5 * time.Second),
+		ConnectRetries:    5,
+		ConnectBackoff:    Duration(500 * time.Millisecond),
+		MaxConnectBackoff: Duration(10 * time.Second),
+		AutoMigrate:       true,
+	}
+}
+
+// LoadConfig builds the Config from the defaults, the config file and the environment.
+func LoadConfig() (Config, error) {
+	cfg := DefaultConfig()
+
+	if path := os.Getenv("DB_CONFIG_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("reading config file: %w", err)
+		}
+		// Unmarshalling into the defaults only overrides the keys present in the file.
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	}
+
+	env := envReader{}
+	env.string("DB_DRIVER", &cfg.Driver)
+	env.string("DB_PATH", &cfg.Path)
+	env.string("DB_USER", &cfg.User)
+	env.string("DB_PASS", &cfg.Password)
+	env.string("DB_HOST", &cfg.Host)
+	env.int("DB_PORT", &cfg.Port)
+	env.string("DB_NAME", &cfg.Name)
+	env.string("DB_TLS", &cfg.TLS)
+	env.int("DB_MAX_OPEN_CONNS", &cfg.MaxOpenConns)
+	env.int("DB_MAX_IDLE_CONNS", &cfg.MaxIdleConns)
+	env.duration("DB_CONN_MAX_LIFETIME", &cfg.ConnMaxLifetime)
+	env.duration("DB_CONN_MAX_IDLE_TIME", &cfg.ConnMaxIdleTime)
+	env.duration("DB_DIAL_TIMEOUT", &cfg.DialTimeout)
+	env.int("DB_CONNECT_RETRIES", &cfg.ConnectRetries)
+	env.duration("DB_CONNECT_BACKOFF", &cfg.ConnectBackoff)
+	env.duration("DB_MAX_CONNECT_BACKOFF", &cfg.MaxConnectBackoff)
+	env.bool("DB_AUTO_MIGRATE", &cfg.AutoMigrate)
+	if env.err != nil {
+		return Config{}, env.err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Validate reports the first setting that can't be used to open a connection.
+func (c Config) Validate() error {
+	switch {
+	case c.MaxOpenConns < 0, c.MaxIdleConns < 0, c.ConnectRetries < 0:
+		return fmt.Errorf("connection pool sizes and retries must not be negative")
+	}
+
+	switch c.Driver {
+	case DriverMySQL:
+		return c.validateMySQL()
+	case DriverSQLite:
+		if c.Path == "" {
+			return fmt.Errorf("database path must not be empty")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported database driver %q", c.Driver)
+	}
+}
+
+func (c Config) validateMySQL() error {
+	switch {
+	case c.Host == "":
+		return fmt.Errorf("database host must not be empty")
+	case c.Port <= 0 || c.Port > 65535:
+		return fmt.Errorf("database port %d is out of range", c.Port)
+	case c.Name == "":
+		return fmt.Errorf("database name must not be empty")
+	}
+
+	switch c.TLS {
+	case "false", "true", "skip-verify", "preferred":
+	default:
+		return fmt.Errorf("unsupported TLS mode %q", c.TLS)
+	}
+	return nil
+}
+
+// Duration is a time.Duration written as a string like "30s" in the config file.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// envReader overrides config values with the environment variables that are set,
+// remembering the first value that fails to parse.
+type envReader struct {
+	err error
+}
+
+func (e *envReader) string(key string, dst *string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+func (e *envReader) int(key string, dst *int) {
+	v, ok := os.LookupEnv(key)
+	if !ok || e.err != nil {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		e.err = fmt.Errorf("%s: %w", key, err)
+		return
+	}
+	*dst = n
+}
+
+func (e *envReader) bool(key string, dst *bool) {
+	v, ok := os.LookupEnv(key)
+	if !ok || e.err != nil {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.err = fmt.Errorf("%s: %w", key, err)
+		return
+	}
+	*dst = b
+}
+
+func (e *envReader) duration(key string, dst *Duration) {
+	v, ok := os.LookupEnv(key)
+	if !ok || e.err != nil {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		e.err = fmt.Errorf("%s: %w", key, err)
+		return
+	}
+	*dst = Duration(d)
+}
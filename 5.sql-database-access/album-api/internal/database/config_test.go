@@ -0,0 +1,81 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	t.Setenv("DB_CONFIG_FILE", "")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if cfg.Host != "127.0.0.1" || cfg.Port != 3306 || cfg.Name != "mysql" {
+		t.Errorf("LoadConfig() address = %s:%d/%s; want the defaults", cfg.Host, cfg.Port, cfg.Name)
+	}
+}
+
+// The environment has to win over the file, and the file over the defaults.
+func TestLoadConfig_FileAndEnvOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+	file := `{"host": "db.internal", "name": "recordings", "connMaxLifetime": "1h", "maxOpenConns": 50}`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DB_CONFIG_FILE", path)
+	t.Setenv("DB_NAME", "albums")
+	t.Setenv("DB_TLS", "true")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if cfg.Host != "db.internal" {
+		t.Errorf("Host = %q; want %q from the file", cfg.Host, "db.internal")
+	}
+	if cfg.Name != "albums" {
+		t.Errorf("Name = %q; want %q from the environment", cfg.Name, "albums")
+	}
+	if cfg.TLS != "true" {
+		t.Errorf("TLS = %q; want %q", cfg.TLS, "true")
+	}
+	if cfg.MaxOpenConns != 50 {
+		t.Errorf("MaxOpenConns = %d; want 50", cfg.MaxOpenConns)
+	}
+	if time.Duration(cfg.ConnMaxLifetime) != time.Hour {
+		t.Errorf("ConnMaxLifetime = %s; want 1h", time.Duration(cfg.ConnMaxLifetime))
+	}
+	if cfg.Port != 3306 {
+		t.Errorf("Port = %d; want the default 3306", cfg.Port)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		val  string
+	}{
+		{"Port not a number", "DB_PORT", "mysql"},
+		{"Port out of range", "DB_PORT", "70000"},
+		{"Unknown TLS mode", "DB_TLS", "always"},
+		{"Bad duration", "DB_CONN_MAX_LIFETIME", "5 minutes"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("DB_CONFIG_FILE", "")
+			t.Setenv(tc.key, tc.val)
+
+			if _, err := LoadConfig(); err == nil {
+				t.Errorf("LoadConfig() with %s=%q succeeded; want an error", tc.key, tc.val)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// The supported values of Config.Driver.
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// NewConnection opens the connection pool described by cfg and verifies it with a ping.
+// A failed ping is retried with exponential backoff, so the service survives the database starting after it.
+// ctx bounds the whole procedure, cancelling it stops the retries, which are logged to logger.
+func NewConnection(ctx context.Context, cfg Config, logger *slog.Logger) (*sql.DB, error) {
+	var (
+		db  *sql.DB
+		err error
+	)
+	switch cfg.Driver {
+	case DriverMySQL:
+		db, err = openMySQL(cfg)
+	case DriverSQLite:
+		db, err = openSQLite(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open sql connection: %w", err)
+	}
+
+	// Creates the actual connection to the db using the connection string and the driver provided earlier
+	if err := pingWithRetry(ctx, db, cfg, logger); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not ping the database: %w", err)
+	}
+
+	return db, nil
+}
+
+// pingWithRetry pings the database until it answers, waiting longer after every failed attempt.
+func pingWithRetry(ctx context.Context, db *sql.DB, cfg Config, logger *slog.Logger) error {
+	backoff := time.Duration(cfg.ConnectBackoff)
+
+	for attempt := 0; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= cfg.ConnectRetries {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt+1, err)
+		}
+
+		logger.WarnContext(ctx, "database not ready, retrying",
+			slog.Int("attempt", attempt+1), slog.Int("attempts", cfg.ConnectRetries+1), slog.Duration("backoff", backoff), slog.Any("error", err))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, time.Duration(cfg.MaxConnectBackoff))
+	}
+}
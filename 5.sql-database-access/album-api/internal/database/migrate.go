@@ -0,0 +1,324 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The migrations are compiled into the binary, so a schema change always ships together with the code that needs it.
+// Every driver has its own directory since the DDL differs between the engines, the versions of both are kept in step.
+//
+//go:embed migrations/mysql/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+// Migration is one versioned schema change read from a pair of files named
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// String returns the file name prefix of the migration, e.g. "0001_create_album_table".
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// MigrationStatus tells whether a migration has been applied and when.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies and reverts the embedded migrations, recording the applied versions in the schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+	logger     *slog.Logger
+}
+
+// migrationLock names the MySQL advisory lock held while migrating,
+// migrationLockTimeout is how many seconds a migrator waits for another one to finish.
+const (
+	migrationLock        = "album-api.schema_migrations"
+	migrationLockTimeout = 300
+)
+
+// querier runs the statements of the migrator, on the pool or on the connection holding the migration lock.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// NewMigrator returns a Migrator for the migrations embedded in the binary for the given driver,
+// logging every migration it applies or reverts to logger.
+func NewMigrator(db *sql.DB, driver string, logger *slog.Logger) (*Migrator, error) {
+	if driver != DriverMySQL && driver != DriverSQLite {
+		return nil, fmt.Errorf("no migrations for database driver %q", driver)
+	}
+	sub, err := fs.Sub(migrationFiles, path.Join("migrations", driver))
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations(sub)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, driver: driver, migrations: migrations, logger: logger}, nil
+}
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// loadMigrations reads every migration in the root of fsys, sorted by version.
+// Every version needs both an up and a down file.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected file %q in migrations", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %s needs both an up and a down file", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// ensureVersionTable creates the table keeping track of the applied migrations.
+func (m *Migrator) ensureVersionTable(ctx context.Context, q querier) error {
+	_, err := q.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version    BIGINT NOT NULL PRIMARY KEY,
+  name       VARCHAR(255) NOT NULL,
+  applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`)
+	if err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// appliedVersions returns the applied versions and the time they were applied at.
+func (m *Migrator) appliedVersions(ctx context.Context, q querier) (map[int]time.Time, error) {
+	if err := m.ensureVersionTable(ctx, q); err != nil {
+		return nil, err
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("reading schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("reading schema_migrations: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// Status lists every known migration along with the time it was applied, nil for the pending ones.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.appliedVersions(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if at, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration in version order and returns how many were applied.
+// It waits for any other migrator working on the same database to finish first, see locked.
+func (m *Migrator) Up(ctx context.Context) (count int, err error) {
+	err = m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			m.logger.InfoContext(ctx, "applying migration", slog.String("migration", migration.String()))
+			err := m.run(ctx, conn, migration.Up, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)", migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("migration %s up: %w", migration, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down reverts the last `steps` applied migrations, newest first, and returns how many were reverted.
+// Like Up, it holds the migration lock while it runs.
+func (m *Migrator) Down(ctx context.Context, steps int) (count int, err error) {
+	err = m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			m.logger.InfoContext(ctx, "reverting migration", slog.String("migration", migration.String()))
+			err := m.run(ctx, conn, migration.Down, "DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+			if err != nil {
+				return fmt.Errorf("migration %s down: %w", migration, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// locked calls migrate with a connection of its own, on MySQL while holding an advisory lock on that connection.
+// Replicas starting together would otherwise all read the same schema version and run the same DDL,
+// with the lock the first one migrates and the others find the migrations applied once they get it.
+// A SQLite database belongs to a single process, it needs no lock.
+func (m *Migrator) locked(ctx context.Context, migrate func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.driver != DriverMySQL {
+		return migrate(conn)
+	}
+
+	// GET_LOCK answers 1 once the lock is held, 0 when the timeout ran out and NULL on an error.
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLock, migrationLockTimeout).Scan(&acquired); err != nil {
+		return fmt.Errorf("acquiring the migration lock: %w", err)
+	}
+	if acquired.Int64 != 1 {
+		return fmt.Errorf("acquiring the migration lock: another migrator held it for %d seconds", migrationLockTimeout)
+	}
+	defer func() {
+		// The lock belongs to the session, not to the transaction, a connection going back to the pool still holding it
+		// would block every later migrator. Released even when ctx is cancelled, or the connection is thrown away.
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT RELEASE_LOCK(?)", migrationLock); err != nil {
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	return migrate(conn)
+}
+
+// run executes the statements of a migration file followed by the bookkeeping statement in one transaction.
+// Note that MySQL commits implicitly before and after DDL statements like CREATE TABLE, the statements
+// before a DDL one included. A failure halfway leaves the earlier statements applied without the bookkeeping,
+// so every statement of a migration has to stay safe to run again: the down of 0005_album_timestamps
+// deletes the soft-deleted albums before dropping the columns, run again it finds none left to delete.
+func (m *Migrator) run(ctx context.Context, q querier, script string, bookkeeping string, args ...any) error {
+	tx, err := q.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range splitStatements(script) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("executing %q: %w", stmt, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return fmt.Errorf("recording migration: %w", err)
+	}
+	return tx.Commit()
+}
+
+// splitStatements splits a migration file into single statements, since the driver runs one statement per Exec.
+// A statement ends with a semicolon at the end of a line, lines starting with "--" are comments.
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func testLogger(t *testing.T) *slog.Logger {
+	return slog.New(slog.NewTextHandler(t.Output(), &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestLoadMigrations_SortedAndPaired(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":            {Data: []byte("CREATE INDEX i ON album (artist);")},
+		"0002_add_index.down.sql":          {Data: []byte("DROP INDEX i ON album;")},
+		"0001_create_album_table.up.sql":   {Data: []byte("CREATE TABLE album (id INT);")},
+		"0001_create_album_table.down.sql": {Data: []byte("DROP TABLE album;")},
+	}
+
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("loadMigrations() error = %v", err)
+	}
+
+	var got []string
+	for _, m := range migrations {
+		got = append(got, m.String())
+	}
+	want := []string{"0001_create_album_table", "0002_add_index"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadMigrations() = %v; want %v", got, want)
+	}
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"Missing down file", fstest.MapFS{
+			"0001_create.up.sql": {Data: []byte("CREATE TABLE t (id INT);")},
+		}},
+		{"Version used twice", fstest.MapFS{
+			"0001_create.up.sql":   {Data: []byte("CREATE TABLE t (id INT);")},
+			"0001_create.down.sql": {Data: []byte("DROP TABLE t;")},
+			"0001_other.up.sql":    {Data: []byte("CREATE TABLE u (id INT);")},
+		}},
+		{"Unexpected file name", fstest.MapFS{
+			"create.sql": {Data: []byte("CREATE TABLE t (id INT);")},
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := loadMigrations(tc.fsys); err == nil {
+				t.Errorf("loadMigrations() succeeded; want an error")
+			}
+		})
+	}
+}
+
+// The embedded migrations have to be loadable, otherwise the binary can't start,
+// and both drivers have to know the same versions.
+func TestNewMigrator_EmbeddedMigrations(t *testing.T) {
+	mysqlMigrator, err := NewMigrator(nil, DriverMySQL, testLogger(t))
+	if err != nil {
+		t.Fatalf("NewMigrator(mysql) error = %v", err)
+	}
+	sqliteMigrator, err := NewMigrator(nil, DriverSQLite, testLogger(t))
+	if err != nil {
+		t.Fatalf("NewMigrator(sqlite) error = %v", err)
+	}
+
+	if len(mysqlMigrator.migrations) == 0 {
+		t.Fatalf("NewMigrator() found no embedded migrations")
+	}
+	if len(mysqlMigrator.migrations) != len(sqliteMigrator.migrations) {
+		t.Fatalf("mysql has %d migrations, sqlite has %d", len(mysqlMigrator.migrations), len(sqliteMigrator.migrations))
+	}
+	for i := range mysqlMigrator.migrations {
+		if mysqlMigrator.migrations[i].String() != sqliteMigrator.migrations[i].String() {
+			t.Errorf("migration %d is %s for mysql but %s for sqlite", i, mysqlMigrator.migrations[i], sqliteMigrator.migrations[i])
+		}
+	}
+}
+
+// Runs every migration up and back down against an in-memory SQLite database.
+func TestMigrator_UpDownSQLite(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Driver = DriverSQLite
+	cfg.Path = InMemory
+	db, err := NewConnection(t.Context(), cfg, testLogger(t))
+	if err != nil {
+		t.Fatalf("NewConnection() error = %v", err)
+	}
+	defer db.Close()
+
+	m, err := NewMigrator(db, DriverSQLite, testLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := m.Up(t.Context())
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if applied != len(m.migrations) {
+		t.Errorf("Up() applied %d migrations; want %d", applied, len(m.migrations))
+	}
+
+	// A second run has nothing left to do.
+	if applied, err := m.Up(t.Context()); err != nil || applied != 0 {
+		t.Errorf("second Up() = %d, %v; want 0, nil", applied, err)
+	}
+
+	statuses, err := m.Status(t.Context())
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("Status() reports %s as pending after Up()", status.Migration)
+		}
+	}
+
+	reverted, err := m.Down(t.Context(), len(m.migrations))
+	if err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if reverted != len(m.migrations) {
+		t.Errorf("Down() reverted %d migrations; want %d", reverted, len(m.migrations))
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- comment
+CREATE TABLE album (
+  id INT
+);
+
+INSERT INTO album VALUES (1);
+`
+	got := splitStatements(script)
+	want := []string{"CREATE TABLE album (\n  id INT\n)", "INSERT INTO album VALUES (1)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitStatements() = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,8 @@
+-- IF NOT EXISTS adopts databases whose album table was created by hand before migrations existed.
+CREATE TABLE IF NOT EXISTS album (
+  id         INT AUTO_INCREMENT NOT NULL,
+  title      VARCHAR(128) NOT NULL,
+  artist     VARCHAR(255) NOT NULL,
+  price      DECIMAL(5,2) NOT NULL,
+  PRIMARY KEY (`id`)
+);
@@ -0,0 +1,4 @@
+-- Fails for prices that don't fit DECIMAL(5,2) anymore.
+ALTER TABLE album
+  DROP COLUMN currency,
+  MODIFY price DECIMAL(5,2) NOT NULL;
@@ -0,0 +1,5 @@
+-- DECIMAL(5,2) topped out at 999.99, the currency decides how many of the 4 decimal places are used.
+-- The currency goes before the price since it has to be scanned first.
+ALTER TABLE album
+  ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' AFTER artist,
+  MODIFY price DECIMAL(19,4) NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE album
+  DROP INDEX album_search;
@@ -0,0 +1,3 @@
+-- Backs the album search, MATCH(title, artist) needs an index on exactly these columns.
+ALTER TABLE album
+  ADD FULLTEXT INDEX album_search (title, artist);
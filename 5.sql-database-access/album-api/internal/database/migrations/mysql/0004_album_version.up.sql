@@ -0,0 +1,3 @@
+-- Incremented by every update, a write carrying an older version is rejected instead of overwriting a concurrent change.
+ALTER TABLE album
+  ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
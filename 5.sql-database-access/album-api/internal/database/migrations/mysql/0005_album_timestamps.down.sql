@@ -0,0 +1,6 @@
+-- The soft-deleted albums are deleted for good, the schema can't tell them apart anymore.
+DELETE FROM album WHERE deleted_at IS NOT NULL;
+ALTER TABLE album
+  DROP COLUMN created_at,
+  DROP COLUMN updated_at,
+  DROP COLUMN deleted_at;
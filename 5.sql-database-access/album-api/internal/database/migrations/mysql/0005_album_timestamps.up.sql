@@ -0,0 +1,6 @@
+-- deleted_at is set instead of deleting the row, so a deleted album can be restored.
+-- The existing albums count as created now, their real creation time is unknown.
+ALTER TABLE album
+  ADD COLUMN created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  ADD COLUMN updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  ADD COLUMN deleted_at DATETIME(6) NULL;
@@ -0,0 +1,13 @@
+-- Append-only history of the album changes, written in the transaction of the change itself.
+-- There is no foreign key on purpose: the history outlives the albums.
+CREATE TABLE IF NOT EXISTS album_audit (
+  id          BIGINT AUTO_INCREMENT NOT NULL,
+  album_id    BIGINT NOT NULL,
+  action      VARCHAR(16) NOT NULL,
+  actor       VARCHAR(255) NOT NULL,
+  before_data JSON NULL,
+  after_data  JSON NULL,
+  created_at  DATETIME(6) NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX album_audit_album (album_id, id)
+);
@@ -0,0 +1,2 @@
+-- DECIMAL(19,4) is exact in MySQL, only SQLite stores the price as TEXT.
+-- This migration only keeps the versions in step with SQLite.
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS album (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  title      VARCHAR(128) NOT NULL,
+  artist     VARCHAR(255) NOT NULL,
+  price      DECIMAL(5,2) NOT NULL
+);
@@ -0,0 +1,9 @@
+CREATE TABLE album_old (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  title      VARCHAR(128) NOT NULL,
+  artist     VARCHAR(255) NOT NULL,
+  price      DECIMAL(5,2) NOT NULL
+);
+INSERT INTO album_old (id, title, artist, price) SELECT id, title, artist, price FROM album;
+DROP TABLE album;
+ALTER TABLE album_old RENAME TO album;
@@ -0,0 +1,11 @@
+-- SQLite can only append columns, so the table is rebuilt to keep the currency in front of the price.
+CREATE TABLE album_new (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  title      VARCHAR(128) NOT NULL,
+  artist     VARCHAR(255) NOT NULL,
+  currency   CHAR(3) NOT NULL DEFAULT 'USD',
+  price      DECIMAL(19,4) NOT NULL
+);
+INSERT INTO album_new (id, title, artist, price) SELECT id, title, artist, price FROM album;
+DROP TABLE album;
+ALTER TABLE album_new RENAME TO album;
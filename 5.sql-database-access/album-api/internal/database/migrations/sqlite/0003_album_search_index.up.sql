@@ -0,0 +1,2 @@
+-- SQLite has no FULLTEXT index, the repository searches with LIKE and ranks the albums itself.
+-- This migration only keeps the versions in step with MySQL.
@@ -0,0 +1,2 @@
+-- Incremented by every update, a write carrying an older version is rejected instead of overwriting a concurrent change.
+ALTER TABLE album ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+-- The soft-deleted albums are deleted for good, the schema can't tell them apart anymore.
+DELETE FROM album WHERE deleted_at IS NOT NULL;
+ALTER TABLE album DROP COLUMN deleted_at;
+ALTER TABLE album DROP COLUMN updated_at;
+ALTER TABLE album DROP COLUMN created_at;
@@ -0,0 +1,6 @@
+-- deleted_at is set instead of deleting the row, so a deleted album can be restored.
+-- SQLite can't add a column defaulting to CURRENT_TIMESTAMP, the existing albums get the time of the migration afterwards.
+ALTER TABLE album ADD COLUMN created_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00';
+ALTER TABLE album ADD COLUMN updated_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00';
+ALTER TABLE album ADD COLUMN deleted_at DATETIME NULL;
+UPDATE album SET created_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP;
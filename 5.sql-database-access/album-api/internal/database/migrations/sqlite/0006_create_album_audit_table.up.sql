@@ -0,0 +1,12 @@
+-- Append-only history of the album changes, written in the transaction of the change itself.
+-- There is no foreign key on purpose: the history outlives the albums.
+CREATE TABLE IF NOT EXISTS album_audit (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  album_id    INTEGER NOT NULL,
+  action      VARCHAR(16) NOT NULL,
+  actor       VARCHAR(255) NOT NULL,
+  before_data TEXT NULL,
+  after_data  TEXT NULL,
+  created_at  DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS album_audit_album ON album_audit (album_id, id);
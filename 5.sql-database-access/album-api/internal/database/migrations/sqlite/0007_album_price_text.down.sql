@@ -0,0 +1,17 @@
+CREATE TABLE album_old (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  title      VARCHAR(128) NOT NULL,
+  artist     VARCHAR(255) NOT NULL,
+  currency   CHAR(3) NOT NULL DEFAULT 'USD',
+  price      DECIMAL(19,4) NOT NULL,
+  version    INTEGER NOT NULL DEFAULT 1,
+  created_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',
+  updated_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',
+  deleted_at DATETIME NULL
+);
+INSERT INTO album_old (id, title, artist, currency, price, version, created_at, updated_at, deleted_at)
+  SELECT id, title, artist, currency, price, version, created_at, updated_at, deleted_at FROM album;
+DELETE FROM sqlite_sequence WHERE name = 'album_old';
+INSERT INTO sqlite_sequence (name, seq) SELECT 'album_old', seq FROM sqlite_sequence WHERE name = 'album';
+DROP TABLE album;
+ALTER TABLE album_old RENAME TO album;
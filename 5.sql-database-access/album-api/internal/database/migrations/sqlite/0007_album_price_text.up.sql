@@ -0,0 +1,20 @@
+-- A DECIMAL column has NUMERIC affinity in SQLite, which stores the price as a REAL and rounds the amounts
+-- past 15 significant digits. As TEXT the decimal string written by the repository is kept as it is.
+CREATE TABLE album_new (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  title      VARCHAR(128) NOT NULL,
+  artist     VARCHAR(255) NOT NULL,
+  currency   CHAR(3) NOT NULL DEFAULT 'USD',
+  price      TEXT NOT NULL,
+  version    INTEGER NOT NULL DEFAULT 1,
+  created_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',
+  updated_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00',
+  deleted_at DATETIME NULL
+);
+INSERT INTO album_new (id, title, artist, currency, price, version, created_at, updated_at, deleted_at)
+  SELECT id, title, artist, currency, CAST(price AS TEXT), version, created_at, updated_at, deleted_at FROM album;
+-- The ids of deleted albums aren't handed out again.
+DELETE FROM sqlite_sequence WHERE name = 'album_new';
+INSERT INTO sqlite_sequence (name, seq) SELECT 'album_new', seq FROM sqlite_sequence WHERE name = 'album';
+DROP TABLE album;
+ALTER TABLE album_new RENAME TO album;
@@ -2,35 +2,38 @@ package database
 
 import (
 	"database/sql"
-	"fmt"
-	"os"
+	"net"
+	"strconv"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 )
 
-func NewConnection() (*sql.DB, error) {
-	// Create a config object from environment variables.
-	cfg := mysql.NewConfig()
-	cfg.User = os.Getenv("DB_USER")
-	cfg.Passwd = os.Getenv("DB_PASS")
-	cfg.Net = "tcp"
-	cfg.Addr = "127.0.0.1:3306"
-	cfg.DBName = "mysql"
-	cfg.ParseTime = true // Apparently, its important for the Go's sql package to work correctly.
+// openMySQL prepares the connection pool for a MySQL server, it doesn't connect yet.
+func openMySQL(cfg Config) (*sql.DB, error) {
+	// Create a driver config object from our config.
+	mysqlCfg := mysql.NewConfig()
+	mysqlCfg.User = cfg.User
+	mysqlCfg.Passwd = cfg.Password
+	mysqlCfg.Net = "tcp"
+	mysqlCfg.Addr = net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	mysqlCfg.DBName = cfg.Name
+	mysqlCfg.TLSConfig = cfg.TLS
+	mysqlCfg.Timeout = time.Duration(cfg.DialTimeout)
+	mysqlCfg.ParseTime = true // Apparently, its important for the Go's sql package to work correctly.
 
-	var err error
 	// Pass the config object after converting it to a connection string.
 	// sql.Open will verify the driver availability and allocate memory for a sql.DB object.
-	db, err := sql.Open("mysql", cfg.FormatDSN())
+	db, err := sql.Open("mysql", mysqlCfg.FormatDSN())
 	if err != nil {
-		return nil, fmt.Errorf("could not open sql connection: %w", err)
+		return nil, err
 	}
 
-	// Creates the actual connection to the mysql db using the connection string and the driver provided earlier
-	err = db.Ping()
-	if err != nil {
-		return nil, fmt.Errorf("could not ping the database: %w", err)
-	}
+	// Tune the connection pool managed by sql.DB.
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
+	db.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTime))
 
 	return db, nil
 }
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"database/sql"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go driver registered as "sqlite", no cgo needed.
+)
+
+// InMemory is the Config.Path of a SQLite database living only as long as the process.
+const InMemory = ":memory:"
+
+// openSQLite prepares the connection pool for the SQLite database file at cfg.Path.
+func openSQLite(cfg Config) (*sql.DB, error) {
+	// Every connection runs these pragmas when it is opened:
+	// foreign keys are off by default in SQLite, and busy_timeout makes a writer wait for the lock instead of failing right away.
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+
+	db, err := sql.Open("sqlite", "file:"+cfg.Path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Path == InMemory {
+		// Every connection to ":memory:" gets its own empty database,
+		// so the pool is pinned to one connection which is never closed.
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		db.SetConnMaxLifetime(0)
+		db.SetConnMaxIdleTime(0)
+		return db, nil
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
+	db.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTime))
+	return db, nil
+}
@@ -0,0 +1,87 @@
+// Package logging builds the slog.Logger of album-api and tags every record logged while serving a request with its id.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Format is the output format of the logs.
+type Format string
+
+const (
+	// Text writes key=value pairs, easy to read in a terminal.
+	Text Format = "text"
+	// JSON writes one JSON object per record, for log collectors.
+	JSON Format = "json"
+)
+
+// ParseFormat returns the Format with the given name, ignoring case.
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(name)); format {
+	case Text, JSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown log format %q, want %q or %q", name, Text, JSON)
+	}
+}
+
+// New returns a logger writing the records of at least the given level to w.
+// The records logged with a context carrying a request id (see WithRequestID) get a request_id attribute.
+func New(w io.Writer, format Format, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch format {
+	case JSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// contextHandler adds the attributes carried by the context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the id of the request it serves.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request id carried by ctx, an empty string when there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random id of 16 hex characters.
+func NewRequestID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{"text", Text, false},
+		{"JSON", JSON, false},
+		{"logfmt", "", true},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseFormat(tc.name)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q (error: %t)", tc.name, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+// decodeRecords parses the JSON records written by a logger.
+func decodeRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for line := range strings.Lines(buf.String()) {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("record %q isn't JSON: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestNew_AddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, JSON, slog.LevelInfo).With("component", "test")
+
+	logger.InfoContext(WithRequestID(t.Context(), "abc"), "inside a request")
+	logger.InfoContext(t.Context(), "outside a request")
+	logger.DebugContext(WithRequestID(t.Context(), "abc"), "below the level")
+
+	records := decodeRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("got %d records; want 2", len(records))
+	}
+	if records[0]["request_id"] != "abc" || records[0]["component"] != "test" {
+		t.Errorf("record inside a request = %v; want request_id abc and the component", records[0])
+	}
+	if _, ok := records[1]["request_id"]; ok {
+		t.Errorf("record outside a request = %v; want no request_id", records[1])
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		wantID func(string) bool
+	}{
+		{"Generated", "", func(id string) bool { return len(id) == 16 }},
+		{"From the client", "req-42", func(id string) bool { return id == "req-42" }},
+		{"Invalid header replaced", "two words", func(id string) bool { return len(id) == 16 }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&buf, JSON, slog.LevelInfo)
+
+			var seen string
+			handler := Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = RequestID(r.Context())
+				w.WriteHeader(http.StatusTeapot)
+				w.Write([]byte("short and stout"))
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/albums/1", nil)
+			if tc.header != "" {
+				req.Header.Set(RequestIDHeader, tc.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(RequestIDHeader)
+			if !tc.wantID(id) || seen != id {
+				t.Errorf("response id = %q, handler saw %q", id, seen)
+			}
+
+			records := decodeRecords(t, &buf)
+			if len(records) != 1 {
+				t.Fatalf("got %d records; want the access log", len(records))
+			}
+			access := records[0]
+			if access["request_id"] != id || access["status"] != float64(http.StatusTeapot) || access["bytes"] != float64(15) || access["path"] != "/albums/1" {
+				t.Errorf("access log = %v", access)
+			}
+		})
+	}
+}
@@ -1,18 +1,143 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
 	"example/web-service-gin/internal/album"
+	"example/web-service-gin/internal/apierror"
+	"example/web-service-gin/internal/auth"
+	"example/web-service-gin/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	router := gin.Default()
+	logger := newLogger()
+	// The log package, used by the store errors and gin itself, writes through the same handler.
+	slog.SetDefault(logger)
+
+	store, err := openStore()
+	if err != nil {
+		log.Fatalf("Error opening the album store. err: %v", err)
+	}
+	if closer, ok := store.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if err := album.Seed(context.Background(), store); err != nil {
+		log.Fatalf("Error seeding the album store. err: %v", err)
+	}
+
+	cfg, err := loadMiddlewareConfig()
+	if err != nil {
+		log.Fatalf("Error reading the middleware configuration. err: %v", err)
+	}
+
+	authenticator, err := loadAuthenticator(logger)
+	if err != nil {
+		log.Fatalf("Error reading the authentication configuration. err: %v", err)
+	}
+
+	controller := album.NewController(store)
+	router := gin.New()
+	router.Use(middleware.Chain(logger, cfg)...)
+	router.Use(auth.Authenticate(authenticator))
+
+	controller.RegisterRoutes(router, auth.RequireRole(auth.RoleEditor))
+	album.RegisterDocs(router)
+	router.NoRoute(func(c *gin.Context) {
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path)
+	})
+
+	if err := router.Run("localhost:8080"); err != nil {
+		log.Printf("Server stopped. err: %v", err)
+	}
+}
 
-	router.GET("/albums", album.GetAlbums)
-	router.POST("/albums", album.PostAlbums)
+// openStore returns the store selected by ALBUM_STORE: "memory" (the default) or "bolt",
+// which keeps the albums in the file named by ALBUM_DB_PATH (albums.db by default).
+func openStore() (album.Store, error) {
+	switch kind := os.Getenv("ALBUM_STORE"); kind {
+	case "", "memory":
+		return album.NewMemoryStore(), nil
+	case "bolt":
+		path := os.Getenv("ALBUM_DB_PATH")
+		if path == "" {
+			path = "albums.db"
+		}
+		store, err := album.OpenBoltStore(path)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown ALBUM_STORE %q, want \"memory\" or \"bolt\"", kind)
+	}
+}
+
+// defaultMaxBodyBytes is the request body limit used without MAX_BODY_BYTES, an album is a few hundred bytes.
+const defaultMaxBodyBytes = 1 << 20
+
+// loadMiddlewareConfig reads CORS_ALLOWED_ORIGINS (a comma separated list of origins, "*" for any)
+// and MAX_BODY_BYTES (0 disables the limit).
+func loadMiddlewareConfig() (middleware.Config, error) {
+	cfg := middleware.Config{MaxBodyBytes: defaultMaxBodyBytes}
+
+	for origin := range strings.SplitSeq(os.Getenv("CORS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.AllowedOrigins = append(cfg.AllowedOrigins, origin)
+		}
+	}
+	if value := os.Getenv("MAX_BODY_BYTES"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("MAX_BODY_BYTES must be a number of bytes, got %q", value)
+		}
+		cfg.MaxBodyBytes = n
+	}
+	return cfg, nil
+}
+
+// loadAuthenticator accepts the API keys of API_KEYS, see auth.ParseAPIKeys, and the bearer tokens
+// signed with JWT_SECRET, checked against JWT_ISSUER and JWT_AUDIENCE when they are set.
+// Without either of them every request is anonymous and the albums can only be read.
+func loadAuthenticator(logger *slog.Logger) (auth.Authenticator, error) {
+	var chain auth.Chain
 
-	router.GET("/albums/:id", album.GetAlbumByID)
+	keys, err := auth.ParseAPIKeys(os.Getenv("API_KEYS"))
+	if err != nil {
+		return nil, fmt.Errorf("API_KEYS: %w", err)
+	}
+	if keys.Len() > 0 {
+		chain = append(chain, keys)
+	}
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		verifier, err := auth.NewJWT([]byte(secret))
+		if err != nil {
+			return nil, fmt.Errorf("JWT_SECRET: %w", err)
+		}
+		verifier.Issuer = os.Getenv("JWT_ISSUER")
+		verifier.Audience = os.Getenv("JWT_AUDIENCE")
+		chain = append(chain, verifier)
+	}
+
+	if len(chain) == 0 {
+		logger.Warn("neither API_KEYS nor JWT_SECRET is set, the albums are read-only")
+	}
+	return chain, nil
+}
 
-	router.Run("localhost:8080")
+// newLogger writes text records to stderr, or JSON ones with LOG_FORMAT=json.
+func newLogger() *slog.Logger {
+	if os.Getenv("LOG_FORMAT") == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, nil))
 }
@@ -0,0 +1,45 @@
+// Command token prints a bearer token the API accepts, signed with the JWT_SECRET it runs with:
+//
+//	JWT_SECRET=... go run ./cmd/token -sub alice -roles editor -ttl 1h
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"example/web-service-gin/internal/auth"
+)
+
+func main() {
+	subject := flag.String("sub", "", "the subject of the token, who the caller is")
+	roles := flag.String("roles", auth.RoleEditor, "the roles of the subject, separated by commas")
+	ttl := flag.Duration("ttl", time.Hour, "how long the token is valid")
+	flag.Parse()
+
+	if *subject == "" {
+		log.Fatal("-sub is required")
+	}
+
+	verifier, err := auth.NewJWT([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		log.Fatalf("Error reading JWT_SECRET. err: %v", err)
+	}
+	verifier.Issuer = os.Getenv("JWT_ISSUER")
+	verifier.Audience = os.Getenv("JWT_AUDIENCE")
+
+	var granted []string
+	for role := range strings.SplitSeq(*roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			granted = append(granted, role)
+		}
+	}
+	token, err := verifier.Sign(*subject, granted, *ttl)
+	if err != nil {
+		log.Fatalf("Error signing the token. err: %v", err)
+	}
+	fmt.Println(token)
+}
@@ -0,0 +1,184 @@
+package album
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// albumsBucket holds one key per album id, the value is the album encoded as JSON.
+var albumsBucket = []byte("albums")
+
+// metaBucket holds the state of the store itself, seededKey is set once Seed ran.
+var (
+	metaBucket = []byte("meta")
+	seededKey  = []byte("seeded")
+)
+
+// BoltStore keeps the albums in a bbolt database file, so they survive a restart.
+// bbolt serializes the write transactions itself, the read ones run concurrently.
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+// OpenBoltStore opens (or creates) the database file at path.
+// bbolt locks the file, a second server started on the same file gives up after a second instead of waiting forever.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(albumsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(metaBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating the buckets: %w", err)
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// Close releases the database file.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// seeded implements seedRecorder.
+func (s *BoltStore) seeded(ctx context.Context) (bool, error) {
+	var done bool
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		done = tx.Bucket(metaBucket).Get(seededKey) != nil
+		return nil
+	})
+	return done, err
+}
+
+// markSeeded implements seedRecorder.
+func (s *BoltStore) markSeeded(ctx context.Context) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(seededKey, []byte(time.Now().UTC().Format(time.RFC3339)))
+	})
+}
+
+// List implements Store.
+func (s *BoltStore) List(ctx context.Context) ([]Album, error) {
+	var albums []Album
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(albumsBucket).ForEach(func(key, value []byte) error {
+			var album Album
+			if err := json.Unmarshal(value, &album); err != nil {
+				return fmt.Errorf("album %s: %w", key, err)
+			}
+			albums = append(albums, album)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing albums: %w", err)
+	}
+
+	// The keys are in byte order, "10" before "2".
+	slices.SortFunc(albums, func(a, b Album) int {
+		return compareIDs(a.ID, b.ID)
+	})
+	return albums, nil
+}
+
+// Get implements Store.
+func (s *BoltStore) Get(ctx context.Context, id string) (Album, error) {
+	var album Album
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		value := tx.Bucket(albumsBucket).Get([]byte(id))
+		if value == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(value, &album)
+	})
+	if err != nil {
+		return Album{}, fmt.Errorf("getting album %q: %w", id, err)
+	}
+	return album, nil
+}
+
+// Add implements Store.
+// The generated ids come from the sequence of the bucket, which is stored along with it.
+func (s *BoltStore) Add(ctx context.Context, album Album) (Album, error) {
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(albumsBucket)
+		if album.ID == "" {
+			last := int64(bucket.Sequence())
+			album.ID = nextFreeID(&last, func(id string) bool {
+				return bucket.Get([]byte(id)) != nil
+			})
+			if err := bucket.SetSequence(uint64(last)); err != nil {
+				return err
+			}
+		}
+		if bucket.Get([]byte(album.ID)) != nil {
+			return ErrDuplicateID
+		}
+
+		value, err := json.Marshal(album)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(album.ID), value)
+	})
+	if err != nil {
+		return Album{}, fmt.Errorf("adding album %q: %w", album.ID, err)
+	}
+	return album, nil
+}
+
+// Update implements Store.
+func (s *BoltStore) Update(ctx context.Context, id string, change func(*Album) error) (Album, error) {
+	var album Album
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(albumsBucket)
+		value := bucket.Get([]byte(id))
+		if value == nil {
+			return ErrNotFound
+		}
+		if err := json.Unmarshal(value, &album); err != nil {
+			return err
+		}
+
+		if err := change(&album); err != nil {
+			return err
+		}
+		album.ID = id
+		value, err := json.Marshal(album)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(id), value)
+	})
+	if err != nil {
+		return Album{}, fmt.Errorf("updating album %q: %w", id, err)
+	}
+	return album, nil
+}
+
+// Delete implements Store.
+func (s *BoltStore) Delete(ctx context.Context, id string) error {
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(albumsBucket)
+		if bucket.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		return bucket.Delete([]byte(id))
+	})
+	if err != nil {
+		return fmt.Errorf("deleting album %q: %w", id, err)
+	}
+	return nil
+}
@@ -1,44 +1,119 @@
 package album
 
 import (
-	"log"
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 )
 
-// albums slice to seed record album data.
-var albums = []Album{
-	{ID: "1", Title: "Blue Train", Artist: "John Coltrane", Price: 56.99},
-	{ID: "2", Title: "Jeru", Artist: "Gerry Mulligan", Price: 17.99},
-	{ID: "3", Title: "Sarah Vaughan and Clifford Brown", Artist: "Sarah Vaughan", Price: 39.99},
+// Controller serves the album endpoints from a Store.
+type Controller struct {
+	store Store
 }
 
-func GetAlbums(c *gin.Context) {
-	c.JSON(http.StatusOK, albums)
+func NewController(store Store) *Controller {
+	registerValidations()
+	return &Controller{store: store}
+}
+
+// RegisterRoutes registers all the album endpoints on the given router.
+// The guard handlers run before the endpoints which change an album, cmd/api passes the role check there.
+func (ctrl *Controller) RegisterRoutes(router gin.IRoutes, guard ...gin.HandlerFunc) {
+	guarded := func(handler gin.HandlerFunc) []gin.HandlerFunc {
+		return append(slices.Clone(guard), handler)
+	}
+
+	router.GET("/albums", ctrl.GetAlbums)
+	router.POST("/albums", guarded(ctrl.PostAlbums)...)
+
+	router.GET("/albums/:id", ctrl.GetAlbumByID)
+	router.PUT("/albums/:id", guarded(ctrl.PutAlbum)...)
+	router.PATCH("/albums/:id", guarded(ctrl.PatchAlbum)...)
+	router.DELETE("/albums/:id", guarded(ctrl.DeleteAlbum)...)
 }
 
-func GetAlbumByID(c *gin.Context) {
-	id := c.Param("id")
+func (ctrl *Controller) GetAlbums(c *gin.Context) {
+	albums, err := ctrl.store.List(c.Request.Context())
+	if err != nil {
+		storeError(c, err)
+		return
+	}
 
-	for _, album := range albums {
-		if album.ID == id {
-			c.JSON(http.StatusOK, album)
-			return
-		}
+	// Encode an empty store as [] instead of null.
+	if albums == nil {
+		albums = []Album{}
 	}
+	c.JSON(http.StatusOK, albums)
+}
 
-	c.JSON(http.StatusNotFound, gin.H{"message": "album not found"})
+func (ctrl *Controller) GetAlbumByID(c *gin.Context) {
+	album, err := ctrl.store.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		storeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, album)
 }
 
-func PostAlbums(c *gin.Context) {
+// PostAlbums adds the album in the request body, the server picks its id when the body has none.
+// An id already taken is a 409 Conflict.
+func (ctrl *Controller) PostAlbums(c *gin.Context) {
 	var newAlbum Album
+	if !bindJSON(c, &newAlbum) {
+		return
+	}
 
-	if err := c.BindJSON(&newAlbum); err != nil {
-		log.Printf("Error creating new album. err: %v", err)
+	created, err := ctrl.store.Add(c.Request.Context(), newAlbum)
+	if err != nil {
+		storeError(c, err)
 		return
 	}
+	c.Header("Location", "/albums/"+created.ID)
+	c.JSON(http.StatusCreated, created)
+}
 
-	albums = append(albums, newAlbum)
-	c.JSON(http.StatusCreated, newAlbum)
+// PutAlbum replaces the whole album with the one in the request body, the id in the path wins over the one in the body.
+func (ctrl *Controller) PutAlbum(c *gin.Context) {
+	var replacement Album
+	if !bindJSON(c, &replacement) {
+		return
+	}
+
+	album, err := ctrl.store.Update(c.Request.Context(), c.Param("id"), func(album *Album) error {
+		*album = replacement
+		return nil
+	})
+	if err != nil {
+		storeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, album)
+}
+
+// PatchAlbum only changes the fields present in the request body.
+func (ctrl *Controller) PatchAlbum(c *gin.Context) {
+	var patch AlbumPatch
+	if !bindJSON(c, &patch) {
+		return
+	}
+
+	album, err := ctrl.store.Update(c.Request.Context(), c.Param("id"), func(album *Album) error {
+		patch.Apply(album)
+		return nil
+	})
+	if err != nil {
+		storeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, album)
+}
+
+// DeleteAlbum answers with 204 No Content once the album is gone, 404 when there was no such album.
+func (ctrl *Controller) DeleteAlbum(c *gin.Context) {
+	if err := ctrl.store.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		storeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
 }
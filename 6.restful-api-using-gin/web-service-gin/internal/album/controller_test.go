@@ -0,0 +1,225 @@
+package album
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example/web-service-gin/internal/apierror"
+	"example/web-service-gin/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter serves the album routes from a memory store holding the seed albums.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	store := NewMemoryStore()
+	if err := Seed(t.Context(), store); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	NewController(store).RegisterRoutes(router)
+	return router
+}
+
+// serve sends a request to the router and returns the recorded response.
+func serve(router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestController_UpdateAndDelete(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantTitle  string
+	}{
+		{"Put", http.MethodPut, "/albums/2", `{"id": "9", "title": "Jeru (Remastered)", "artist": "Gerry Mulligan", "price": 19.99}`, http.StatusOK, "Jeru (Remastered)"},
+		{"Put missing album", http.MethodPut, "/albums/9", `{"title": "Jeru", "artist": "Gerry Mulligan", "price": 19.99}`, http.StatusNotFound, ""},
+		{"Patch", http.MethodPatch, "/albums/2", `{"price": {"amount": "19.99", "currency": "USD"}}`, http.StatusOK, "Jeru"},
+		{"Patch missing album", http.MethodPatch, "/albums/9", `{"title": "Jeru"}`, http.StatusNotFound, ""},
+		{"Delete", http.MethodDelete, "/albums/2", "", http.StatusNoContent, ""},
+		{"Delete missing album", http.MethodDelete, "/albums/9", "", http.StatusNotFound, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newTestRouter(t)
+			rec := serve(router, tc.method, tc.path, tc.body)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d; want %d (body %s)", rec.Code, tc.wantStatus, rec.Body)
+			}
+			if tc.wantStatus == http.StatusNoContent && rec.Body.Len() != 0 {
+				t.Errorf("204 response has a body: %s", rec.Body)
+			}
+			if tc.wantTitle == "" {
+				return
+			}
+
+			var album Album
+			if err := json.Unmarshal(rec.Body.Bytes(), &album); err != nil {
+				t.Fatal(err)
+			}
+			if album.ID != "2" || album.Title != tc.wantTitle || album.Price.String() != "19.99 USD" {
+				t.Errorf("album = %+v; want id 2, title %q and price 19.99 USD", album, tc.wantTitle)
+			}
+		})
+	}
+}
+
+func TestController_DeletedAlbumIsGone(t *testing.T) {
+	router := newTestRouter(t)
+	if rec := serve(router, http.MethodDelete, "/albums/1", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d", rec.Code)
+	}
+	if rec := serve(router, http.MethodGet, "/albums/1", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET of a deleted album status = %d; want 404", rec.Code)
+	}
+}
+
+func TestController_PostAlbums(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantCode    string
+		wantFields  []string
+		wantAlbumID string
+	}{
+		{"Generated id", `{"title": "Giant Steps", "artist": "John Coltrane", "price": 63.99}`, http.StatusCreated, "", nil, "4"},
+		{"Client id", `{"id": "giant-steps", "title": "Giant Steps", "artist": "John Coltrane", "price": 63.99}`, http.StatusCreated, "", nil, "giant-steps"},
+		{"Duplicate id", `{"id": "1", "title": "Giant Steps", "artist": "John Coltrane", "price": 63.99}`, http.StatusConflict, apierror.CodeConflict, nil, ""},
+		{"Missing fields", `{"price": 63.99}`, http.StatusUnprocessableEntity, apierror.CodeValidationFailed, []string{"title", "artist"}, ""},
+		{"Negative price", `{"title": "Giant Steps", "artist": "John Coltrane", "price": -1}`, http.StatusUnprocessableEntity, apierror.CodeValidationFailed, []string{"price"}, ""},
+		{"Missing price", `{"title": "Giant Steps", "artist": "John Coltrane"}`, http.StatusUnprocessableEntity, apierror.CodeValidationFailed, []string{"price"}, ""},
+		{"Unknown currency", `{"title": "Giant Steps", "artist": "John Coltrane", "price": {"amount": "1", "currency": "dollars"}}`, http.StatusUnprocessableEntity, apierror.CodeValidationFailed, []string{"price"}, ""},
+		{"Id unsafe in a path", `{"id": "a/b", "title": "Giant Steps", "artist": "John Coltrane", "price": 63.99}`, http.StatusUnprocessableEntity, apierror.CodeValidationFailed, []string{"id"}, ""},
+		{"Malformed JSON", `{"title": `, http.StatusBadRequest, apierror.CodeBadRequest, nil, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(newTestRouter(t), http.MethodPost, "/albums", tc.body)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d; want %d (body %s)", rec.Code, tc.wantStatus, rec.Body)
+			}
+
+			if tc.wantCode == "" {
+				var album Album
+				if err := json.Unmarshal(rec.Body.Bytes(), &album); err != nil {
+					t.Fatal(err)
+				}
+				if album.ID != tc.wantAlbumID || rec.Header().Get("Location") != "/albums/"+tc.wantAlbumID {
+					t.Errorf("created album %+v at %q; want id %s", album, rec.Header().Get("Location"), tc.wantAlbumID)
+				}
+				return
+			}
+			assertError(t, rec, tc.wantCode, tc.wantFields...)
+		})
+	}
+}
+
+func TestController_PatchAlbum_Validation(t *testing.T) {
+	router := newTestRouter(t)
+	rec := serve(router, http.MethodPatch, "/albums/1", `{"title": "", "price": -5}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d; want 422 (body %s)", rec.Code, rec.Body)
+	}
+	assertError(t, rec, apierror.CodeValidationFailed, "title", "price")
+
+	if rec := serve(router, http.MethodGet, "/albums/1", ""); !strings.Contains(rec.Body.String(), "Blue Train") {
+		t.Errorf("album changed by an invalid patch: %s", rec.Body)
+	}
+}
+
+func TestController_NotFoundEnvelope(t *testing.T) {
+	rec := serve(newTestRouter(t), http.MethodGet, "/albums/9", "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d; want 404", rec.Code)
+	}
+	assertError(t, rec, apierror.CodeNotFound)
+}
+
+// brokenStore fails every read like a store whose disk went away.
+type brokenStore struct{ Store }
+
+func (brokenStore) Get(context.Context, string) (Album, error) {
+	return Album{}, errors.New("bolt: database not open")
+}
+
+func TestController_StoreErrorLogged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.AccessLog(slog.New(slog.NewJSONHandler(&logs, nil))))
+	NewController(brokenStore{NewMemoryStore()}).RegisterRoutes(router)
+
+	rec := serve(router, http.MethodGet, "/albums/1", "")
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "bolt") {
+		t.Fatalf("status = %d, body %s; want a 500 hiding the store error", rec.Code, rec.Body)
+	}
+	assertError(t, rec, apierror.CodeInternal)
+
+	var record map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("access log %q isn't one JSON record: %v", logs.String(), err)
+	}
+	if record["error"] != "Error #01: bolt: database not open\n" || record["request_id"] != rec.Header().Get(middleware.RequestIDHeader) {
+		t.Errorf("access log %v; want the store error with the request id", record)
+	}
+}
+
+// assertError checks the error envelope of a response, with a detail for each of the given fields.
+func assertError(t *testing.T, rec *httptest.ResponseRecorder, wantCode string, wantFields ...string) {
+	t.Helper()
+	var resp apierror.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("body %s isn't an error envelope: %v", rec.Body, err)
+	}
+	if resp.Error.Code != wantCode || resp.Error.Message == "" {
+		t.Errorf("error = %+v; want code %q and a message", resp.Error, wantCode)
+	}
+
+	var fields []string
+	for _, detail := range resp.Error.Details {
+		if detail.Message == "" {
+			t.Errorf("detail %+v has no message", detail)
+		}
+		fields = append(fields, detail.Field)
+	}
+	if strings.Join(fields, ",") != strings.Join(wantFields, ",") {
+		t.Errorf("details on %v; want %v", fields, wantFields)
+	}
+}
+
+func TestController_BodyTooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.BodyLimit(16))
+	NewController(NewMemoryStore()).RegisterRoutes(router)
+
+	// A streamed body has no Content-Length, the limit trips while it is bound.
+	body := io.MultiReader(strings.NewReader(`{"title": "Giant Steps", `), strings.NewReader(`"artist": "John Coltrane", "price": 63.99}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/albums", body))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d; want 413 (body %s)", rec.Code, rec.Body)
+	}
+	assertError(t, rec, apierror.CodeTooLarge)
+}
@@ -0,0 +1,24 @@
+package album
+
+import (
+	"errors"
+	"net/http"
+
+	"example/web-service-gin/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// storeError answers with the status matching an error of the store, anything unexpected is hidden behind a 500.
+// The error is attached to the context, the access log records it along with the request id.
+func storeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, ErrNotFound.Error())
+	case errors.Is(err, ErrDuplicateID):
+		apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, ErrDuplicateID.Error())
+	default:
+		c.Error(err)
+		apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+	}
+}
@@ -0,0 +1,90 @@
+package album
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// MemoryStore keeps the albums in a map, they are gone when the server stops.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	albums map[string]Album
+	// lastID is the last generated id.
+	lastID int64
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{albums: make(map[string]Album)}
+}
+
+// List implements Store.
+func (s *MemoryStore) List(ctx context.Context) ([]Album, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.SortedFunc(maps.Values(s.albums), func(a, b Album) int {
+		return compareIDs(a.ID, b.ID)
+	}), nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(ctx context.Context, id string) (Album, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	album, ok := s.albums[id]
+	if !ok {
+		return Album{}, ErrNotFound
+	}
+	return album, nil
+}
+
+// Add implements Store.
+func (s *MemoryStore) Add(ctx context.Context, album Album) (Album, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if album.ID == "" {
+		album.ID = nextFreeID(&s.lastID, func(id string) bool {
+			_, taken := s.albums[id]
+			return taken
+		})
+	}
+	if _, ok := s.albums[album.ID]; ok {
+		return Album{}, ErrDuplicateID
+	}
+	s.albums[album.ID] = album
+	return album, nil
+}
+
+// Update implements Store.
+func (s *MemoryStore) Update(ctx context.Context, id string, change func(*Album) error) (Album, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	album, ok := s.albums[id]
+	if !ok {
+		return Album{}, ErrNotFound
+	}
+	if err := change(&album); err != nil {
+		return Album{}, err
+	}
+	album.ID = id
+	s.albums[id] = album
+	return album, nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.albums[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.albums, id)
+	return nil
+}
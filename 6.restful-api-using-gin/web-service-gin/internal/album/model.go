@@ -1,9 +1,33 @@
 package album
 
+import "example/money"
+
 // Encoding each fields with json encoder and ordering it to keep the key names as indicated in the double quotes.
+// The binding tags are checked by gin when the album is bound from a request body, see registerValidations.
 type Album struct {
-	ID     string  `json:"id"`
-	Title  string  `json:"title"`
-	Artist string  `json:"artist"`
-	Price  float64 `json:"price"`
+	// ID is generated by the server when a new album comes without one.
+	ID     string      `json:"id" binding:"omitempty,max=64,album_id"`
+	Title  string      `json:"title" binding:"required,max=255"`
+	Artist string      `json:"artist" binding:"required,max=255"`
+	Price  money.Money `json:"price" binding:"price"`
+}
+
+// AlbumPatch holds the fields of a partial update, the fields missing from the request body stay nil and are left untouched.
+type AlbumPatch struct {
+	Title  *string      `json:"title" binding:"omitnil,min=1,max=255"`
+	Artist *string      `json:"artist" binding:"omitnil,min=1,max=255"`
+	Price  *money.Money `json:"price" binding:"omitnil,price"`
+}
+
+// Apply copies every non-nil field of the patch onto the album.
+func (p AlbumPatch) Apply(album *Album) {
+	if p.Title != nil {
+		album.Title = *p.Title
+	}
+	if p.Artist != nil {
+		album.Artist = *p.Artist
+	}
+	if p.Price != nil {
+		album.Price = *p.Price
+	}
 }
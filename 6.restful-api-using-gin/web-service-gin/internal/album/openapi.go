@@ -0,0 +1,29 @@
+package album
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpec is the OpenAPI 3 document of the routes of RegisterRoutes,
+// TestOpenAPISpec fails when the two drift apart.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// docsPage renders openAPISpec in the browser, it has no dependencies outside of this server.
+//
+//go:embed docs.html
+var docsPage []byte
+
+// RegisterDocs serves the OpenAPI document at /openapi.json and a page rendering it at /docs.
+func RegisterDocs(router gin.IRoutes) {
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", openAPISpec)
+	})
+	router.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+	})
+}
@@ -0,0 +1,214 @@
+package album
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"example/web-service-gin/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPIDoc is the part of the OpenAPI document the drift checks read.
+type openAPIDoc struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Parameters map[string]openAPIParameter `json:"parameters"`
+		Schemas    map[string]openAPISchema    `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPIParameter struct {
+	Ref  string `json:"$ref"`
+	Name string `json:"name"`
+	In   string `json:"in"`
+}
+
+type openAPISchema struct {
+	Required   []string                 `json:"required"`
+	Properties map[string]openAPISchema `json:"properties"`
+	Enum       []string                 `json:"enum"`
+}
+
+func loadOpenAPIDoc(t *testing.T) openAPIDoc {
+	t.Helper()
+	var doc openAPIDoc
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("openapi.json isn't valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Fatalf("openapi = %q; want an OpenAPI 3 document", doc.OpenAPI)
+	}
+	return doc
+}
+
+// ginParam matches the :name wildcards of a gin path, written {name} in OpenAPI.
+var ginParam = regexp.MustCompile(`:(\w+)`)
+
+// TestOpenAPISpec_Routes fails when a route is registered without being documented, or documented without being registered.
+func TestOpenAPISpec_Routes(t *testing.T) {
+	doc := loadOpenAPIDoc(t)
+
+	var routes []string
+	for _, route := range newTestRouter(t).Routes() {
+		routes = append(routes, route.Method+" "+ginParam.ReplaceAllString(route.Path, "{$1}"))
+	}
+
+	var documented []string
+	for path, item := range doc.Paths {
+		for key, raw := range item {
+			if key == "parameters" {
+				continue
+			}
+			documented = append(documented, strings.ToUpper(key)+" "+path)
+
+			// Every {name} of the path has to be a documented path parameter.
+			var operation struct {
+				Parameters []openAPIParameter `json:"parameters"`
+			}
+			if err := json.Unmarshal(raw, &operation); err != nil {
+				t.Fatalf("%s %s: %v", key, path, err)
+			}
+			var shared []openAPIParameter
+			if raw, ok := item["parameters"]; ok {
+				if err := json.Unmarshal(raw, &shared); err != nil {
+					t.Fatalf("%s parameters: %v", path, err)
+				}
+			}
+			for _, match := range regexp.MustCompile(`\{(\w+)\}`).FindAllStringSubmatch(path, -1) {
+				if !hasPathParameter(doc, append(shared, operation.Parameters...), match[1]) {
+					t.Errorf("%s %s doesn't document the path parameter %q", strings.ToUpper(key), path, match[1])
+				}
+			}
+		}
+	}
+
+	slices.Sort(routes)
+	slices.Sort(documented)
+	if !slices.Equal(routes, documented) {
+		t.Errorf("registered routes %v\ndon't match the documented ones %v", routes, documented)
+	}
+}
+
+func hasPathParameter(doc openAPIDoc, parameters []openAPIParameter, name string) bool {
+	for _, param := range parameters {
+		if ref, ok := strings.CutPrefix(param.Ref, "#/components/parameters/"); ok {
+			param = doc.Components.Parameters[ref]
+		}
+		if param.In == "path" && param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TestOpenAPISpec_Security fails when the operations documented as needing credentials aren't the guarded ones.
+func TestOpenAPISpec_Security(t *testing.T) {
+	doc := loadOpenAPIDoc(t)
+
+	gin.SetMode(gin.TestMode)
+	guarded := gin.New()
+	NewController(NewMemoryStore()).RegisterRoutes(guarded, func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	})
+
+	for path, item := range doc.Paths {
+		for key, raw := range item {
+			if key == "parameters" {
+				continue
+			}
+			var operation struct {
+				Security []map[string][]string `json:"security"`
+			}
+			if err := json.Unmarshal(raw, &operation); err != nil {
+				t.Fatalf("%s %s: %v", key, path, err)
+			}
+
+			method := strings.ToUpper(key)
+			rec := serve(guarded, method, strings.ReplaceAll(path, "{id}", "1"), "{}")
+			isGuarded := rec.Code == http.StatusUnauthorized
+			if documented := len(operation.Security) > 0; documented != isGuarded {
+				t.Errorf("%s %s documents security = %t; want %t", method, path, documented, isGuarded)
+			}
+		}
+	}
+}
+
+// TestOpenAPISpec_Schemas fails when the documented schemas drift from the JSON and binding tags of the models.
+func TestOpenAPISpec_Schemas(t *testing.T) {
+	doc := loadOpenAPIDoc(t)
+
+	tests := []struct {
+		schema string
+		model  any
+	}{
+		{"Album", Album{}},
+		{"AlbumPatch", AlbumPatch{}},
+		{"FieldDetail", apierror.FieldDetail{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.schema, func(t *testing.T) {
+			schema, ok := doc.Components.Schemas[tc.schema]
+			if !ok {
+				t.Fatalf("no %s schema", tc.schema)
+			}
+
+			var properties, required []string
+			modelType := reflect.TypeOf(tc.model)
+			for i := range modelType.NumField() {
+				field := modelType.Field(i)
+				name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+				properties = append(properties, name)
+				// A field is required unless its binding tag lets it be left out,
+				// the structs without binding tags always have every field except the omitempty ones.
+				binding, hasBinding := field.Tag.Lookup("binding")
+				optional := strings.HasPrefix(binding, "omit") || (!hasBinding && strings.Contains(field.Tag.Get("json"), "omitempty"))
+				if !optional {
+					required = append(required, name)
+				}
+			}
+
+			documented := slices.Sorted(maps.Keys(schema.Properties))
+			slices.Sort(properties)
+			if !slices.Equal(properties, documented) {
+				t.Errorf("properties %v; want the JSON keys %v", documented, properties)
+			}
+			slices.Sort(required)
+			documentedRequired := slices.Sorted(slices.Values(schema.Required))
+			if !slices.Equal(required, documentedRequired) {
+				t.Errorf("required %v; want %v", documentedRequired, required)
+			}
+		})
+	}
+
+	codes := doc.Components.Schemas["ErrorResponse"].Properties["error"].Properties["code"].Enum
+	if !slices.Equal(slices.Sorted(slices.Values(codes)), slices.Sorted(slices.Values(apierror.Codes))) {
+		t.Errorf("documented error codes %v; want %v", codes, apierror.Codes)
+	}
+}
+
+func TestRegisterDocs(t *testing.T) {
+	router := newTestRouter(t)
+	RegisterDocs(router)
+
+	tests := []struct {
+		path            string
+		wantContentType string
+	}{
+		{"/openapi.json", "application/json"},
+		{"/docs", "text/html; charset=utf-8"},
+	}
+	for _, tc := range tests {
+		rec := serve(router, http.MethodGet, tc.path, "")
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != tc.wantContentType || rec.Body.Len() == 0 {
+			t.Errorf("GET %s = %d %q with %d bytes; want 200 %q", tc.path, rec.Code, rec.Header().Get("Content-Type"), rec.Body.Len(), tc.wantContentType)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package album
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"strconv"
+
+	"example/money"
+)
+
+// ErrNotFound is returned by a Store when no album has the requested id.
+var ErrNotFound = errors.New("album not found")
+
+// ErrDuplicateID is returned by Store.Add when an album with the same id is already stored.
+var ErrDuplicateID = errors.New("an album with this id already exists")
+
+// Store keeps the albums, the controllers only ever go through it.
+// Every implementation is safe for concurrent use by the handlers.
+type Store interface {
+	// List returns every album ordered by id, see compareIDs.
+	List(ctx context.Context) ([]Album, error)
+	// Get returns the album with the given id or ErrNotFound.
+	Get(ctx context.Context, id string) (Album, error)
+	// Add stores a new album and returns it as stored, failing with ErrDuplicateID when its id is taken.
+	// An album without an id gets the lowest free number above the ones generated before.
+	Add(ctx context.Context, album Album) (Album, error)
+	// Update applies change to the stored album with the given id and stores the result, all at once:
+	// no other write can happen between the read and the write. The id can't be changed.
+	// It returns the stored album, ErrNotFound, or the error of change, in which case nothing is written.
+	Update(ctx context.Context, id string, change func(*Album) error) (Album, error)
+	// Delete removes the album with the given id or fails with ErrNotFound.
+	Delete(ctx context.Context, id string) error
+}
+
+// seedAlbums is the record album data a new, empty store starts with.
+var seedAlbums = []Album{
+	{ID: "1", Title: "Blue Train", Artist: "John Coltrane", Price: money.MustParse("56.99", "USD")},
+	{ID: "2", Title: "Jeru", Artist: "Gerry Mulligan", Price: money.MustParse("17.99", "USD")},
+	{ID: "3", Title: "Sarah Vaughan and Clifford Brown", Artist: "Sarah Vaughan", Price: money.MustParse("39.99", "USD")},
+}
+
+// seedRecorder is implemented by the stores which outlive the server, they remember having been seeded
+// so the seed albums don't come back after every album was deleted.
+type seedRecorder interface {
+	seeded(ctx context.Context) (bool, error)
+	markSeeded(ctx context.Context) error
+}
+
+// Seed adds the seed albums to a store without any album, a store already holding albums is left alone.
+// A store implementing seedRecorder is only ever seeded once, even when it is emptied afterwards.
+func Seed(ctx context.Context, store Store) error {
+	recorder, records := store.(seedRecorder)
+	if records {
+		if done, err := recorder.seeded(ctx); err != nil || done {
+			return err
+		}
+	}
+
+	albums, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(albums) == 0 {
+		for _, album := range seedAlbums {
+			if _, err := store.Add(ctx, album); err != nil {
+				return err
+			}
+		}
+	}
+
+	if records {
+		return recorder.markSeeded(ctx)
+	}
+	return nil
+}
+
+// compareIDs orders the shorter ids first and the ids of the same length lexically,
+// so numeric ids like "2" and "10" are in numeric order.
+func compareIDs(a, b string) int {
+	return cmp.Or(cmp.Compare(len(a), len(b)), cmp.Compare(a, b))
+}
+
+// nextFreeID increments last until its decimal form isn't taken, a client may have picked some numbers as ids already.
+func nextFreeID(last *int64, taken func(id string) bool) string {
+	for {
+		*last++
+		if id := strconv.FormatInt(*last, 10); !taken(id) {
+			return id
+		}
+	}
+}
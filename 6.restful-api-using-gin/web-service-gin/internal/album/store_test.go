@@ -0,0 +1,223 @@
+package album
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"example/money"
+)
+
+// testStore runs the behavior every Store has to have against the stores returned by newStore.
+func testStore(t *testing.T, newStore func(t *testing.T) Store) {
+	t.Run("Seed", func(t *testing.T) {
+		store := newStore(t)
+		for range 2 {
+			if err := Seed(t.Context(), store); err != nil {
+				t.Fatalf("Seed() error = %v", err)
+			}
+		}
+		albums, err := store.List(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(albums) != len(seedAlbums) {
+			t.Errorf("List() after seeding twice returned %d albums; want %d", len(albums), len(seedAlbums))
+		}
+	})
+
+	t.Run("AddGetList", func(t *testing.T) {
+		store := newStore(t)
+		for _, id := range []string{"10", "2", "1"} {
+			album := Album{ID: id, Title: "Jeru", Artist: "Gerry Mulligan", Price: money.MustParse("17.99", "USD")}
+			if _, err := store.Add(t.Context(), album); err != nil {
+				t.Fatalf("Add(%s) error = %v", id, err)
+			}
+		}
+
+		got, err := store.Get(t.Context(), "2")
+		if err != nil || got.ID != "2" || got.Price != money.MustParse("17.99", "USD") {
+			t.Errorf("Get(2) = %+v, %v", got, err)
+		}
+		if _, err := store.Get(t.Context(), "3"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get() of a missing album error = %v; want ErrNotFound", err)
+		}
+		if _, err := store.Add(t.Context(), Album{ID: "2"}); !errors.Is(err, ErrDuplicateID) {
+			t.Errorf("Add() of a taken id error = %v; want ErrDuplicateID", err)
+		}
+
+		albums, err := store.List(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, album := range albums {
+			ids = append(ids, album.ID)
+		}
+		if len(ids) != 3 || ids[0] != "1" || ids[1] != "2" || ids[2] != "10" {
+			t.Errorf("List() ids = %v; want [1 2 10]", ids)
+		}
+	})
+
+	t.Run("GeneratedIDs", func(t *testing.T) {
+		store := newStore(t)
+		// "2" is taken by a client, the generated ids skip it.
+		if _, err := store.Add(t.Context(), Album{ID: "2", Title: "Jeru", Price: money.MustParse("17.99", "USD")}); err != nil {
+			t.Fatal(err)
+		}
+
+		var ids []string
+		for range 3 {
+			album, err := store.Add(t.Context(), Album{Title: "Jeru", Price: money.MustParse("17.99", "USD")})
+			if err != nil {
+				t.Fatalf("Add() without an id error = %v", err)
+			}
+			ids = append(ids, album.ID)
+		}
+		if !slices.Equal(ids, []string{"1", "3", "4"}) {
+			t.Errorf("generated ids = %v; want [1 3 4]", ids)
+		}
+
+		// A deleted id isn't handed out again.
+		if err := store.Delete(t.Context(), "4"); err != nil {
+			t.Fatal(err)
+		}
+		album, err := store.Add(t.Context(), Album{Title: "Jeru", Price: money.MustParse("17.99", "USD")})
+		if err != nil || album.ID != "5" {
+			t.Errorf("Add() after a delete = %+v, %v; want id 5", album, err)
+		}
+	})
+
+	t.Run("UpdateDelete", func(t *testing.T) {
+		store := newStore(t)
+		if err := Seed(t.Context(), store); err != nil {
+			t.Fatal(err)
+		}
+
+		updated, err := store.Update(t.Context(), "2", func(album *Album) error {
+			album.ID, album.Title = "changed", "Jeru (Remastered)"
+			return nil
+		})
+		if err != nil || updated.ID != "2" || updated.Title != "Jeru (Remastered)" {
+			t.Errorf("Update() = %+v, %v; want the new title under the same id", updated, err)
+		}
+		if got, _ := store.Get(t.Context(), "2"); got != updated {
+			t.Errorf("stored album = %+v; want %+v", got, updated)
+		}
+
+		failure := errors.New("rejected")
+		if _, err := store.Update(t.Context(), "2", func(album *Album) error {
+			album.Title = "never stored"
+			return failure
+		}); !errors.Is(err, failure) {
+			t.Errorf("Update() with a failing change error = %v; want its error", err)
+		}
+		if got, _ := store.Get(t.Context(), "2"); got != updated {
+			t.Errorf("stored album after a failed update = %+v; want %+v", got, updated)
+		}
+		if _, err := store.Update(t.Context(), "missing", func(*Album) error { return nil }); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Update() of a missing album error = %v; want ErrNotFound", err)
+		}
+
+		if err := store.Delete(t.Context(), "2"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if _, err := store.Get(t.Context(), "2"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get() of a deleted album error = %v; want ErrNotFound", err)
+		}
+		if err := store.Delete(t.Context(), "2"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Delete() of a deleted album error = %v; want ErrNotFound", err)
+		}
+	})
+
+	t.Run("ConcurrentAdds", func(t *testing.T) {
+		store := newStore(t)
+		var wg sync.WaitGroup
+		for i := range 20 {
+			wg.Go(func() {
+				store.Add(t.Context(), Album{ID: string(rune('a' + i)), Title: "Jeru", Price: money.MustParse("17.99", "USD")})
+			})
+		}
+		wg.Wait()
+
+		albums, err := store.List(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(albums) != 20 {
+			t.Errorf("List() returned %d albums; want the 20 added concurrently", len(albums))
+		}
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func(*testing.T) Store {
+		return NewMemoryStore()
+	})
+}
+
+func TestBoltStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store {
+		store, err := OpenBoltStore(filepath.Join(t.TempDir(), "albums.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	})
+}
+
+func TestBoltStore_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "albums.db")
+	store, err := OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Seed(t.Context(), store); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, err = OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	album, err := store.Get(t.Context(), "1")
+	if err != nil || album.Title != "Blue Train" {
+		t.Errorf("Get(1) after reopening = %+v, %v; want Blue Train", album, err)
+	}
+}
+
+func TestBoltStore_SeedsOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "albums.db")
+	store, err := OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Seed(t.Context(), store); err != nil {
+		t.Fatal(err)
+	}
+	for _, album := range seedAlbums {
+		if err := store.Delete(t.Context(), album.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.Close()
+
+	// The server seeds again on every start, the emptied store has to stay empty.
+	store, err = OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := Seed(t.Context(), store); err != nil {
+		t.Fatal(err)
+	}
+	albums, err := store.List(t.Context())
+	if err != nil || len(albums) != 0 {
+		t.Errorf("List() after deleting every album and seeding again = %v, %v; want no album", albums, err)
+	}
+}
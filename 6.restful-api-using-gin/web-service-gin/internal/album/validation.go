@@ -0,0 +1,110 @@
+package album
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"example/money"
+	"example/web-service-gin/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var registerOnce sync.Once
+
+// registerValidations teaches the gin validator the tags used by the binding tags of Album and AlbumPatch,
+// and makes it name the fields like their JSON keys.
+func registerValidations() {
+	registerOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			panic("album: gin isn't using go-playground/validator")
+		}
+
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+		if err := v.RegisterValidation("album_id", validID); err != nil {
+			panic(err)
+		}
+		if err := v.RegisterValidation("price", validPrice); err != nil {
+			panic(err)
+		}
+	})
+}
+
+// validID accepts the ids made of letters, digits, "-" and "_", which are safe in a URL path.
+func validID(fl validator.FieldLevel) bool {
+	for _, r := range fl.Field().String() {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// validPrice accepts a money.Money with a currency and an amount of at least zero.
+func validPrice(fl validator.FieldLevel) bool {
+	price, ok := fl.Field().Interface().(money.Money)
+	return ok && price.Validate() == nil && !price.IsNegative()
+}
+
+// bindJSON decodes and validates the request body into obj,
+// on failure it writes the error envelope and returns false.
+func bindJSON(c *gin.Context, obj any) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	var (
+		verrs    validator.ValidationErrors
+		tooLarge *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &verrs):
+		details := make([]apierror.FieldDetail, 0, len(verrs))
+		for _, ferr := range verrs {
+			details = append(details, apierror.FieldDetail{Field: ferr.Field(), Message: fieldMessage(ferr)})
+		}
+		apierror.Abort(c, http.StatusUnprocessableEntity, apierror.CodeValidationFailed, "the album is invalid", details...)
+	case errors.Is(err, money.ErrInvalid):
+		// A price which can't even be decoded, like an unknown currency.
+		apierror.Abort(c, http.StatusUnprocessableEntity, apierror.CodeValidationFailed, "the album is invalid",
+			apierror.FieldDetail{Field: "price", Message: err.Error()})
+	case errors.As(err, &tooLarge):
+		apierror.Abort(c, http.StatusRequestEntityTooLarge, apierror.CodeTooLarge,
+			fmt.Sprintf("request body must not be larger than %d bytes", tooLarge.Limit))
+	default:
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeBadRequest, "request body must be valid JSON: "+err.Error())
+	}
+	return false
+}
+
+// fieldMessage describes a failed binding tag in words.
+func fieldMessage(ferr validator.FieldError) string {
+	switch ferr.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters long", ferr.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters long", ferr.Param())
+	case "album_id":
+		return `may only contain letters, digits, "-" and "_"`
+	case "price":
+		return "must be an amount of at least zero in an ISO 4217 currency"
+	default:
+		return fmt.Sprintf("failed the %q check", ferr.Tag())
+	}
+}
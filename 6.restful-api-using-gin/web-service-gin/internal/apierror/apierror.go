@@ -0,0 +1,43 @@
+// Package apierror is the JSON error envelope every failed request is answered with,
+// whether it fails in a handler or in a middleware.
+package apierror
+
+import "github.com/gin-gonic/gin"
+
+// The codes of the error envelope, a client branches on them instead of the message.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidationFailed = "validation_failed"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeTooLarge         = "request_too_large"
+	CodeInternal         = "internal_error"
+)
+
+// Codes lists every code above.
+var Codes = []string{CodeBadRequest, CodeValidationFailed, CodeNotFound, CodeConflict, CodeUnauthorized, CodeForbidden, CodeTooLarge, CodeInternal}
+
+// Response is the body of every failed request: {"error": {"code": ..., "message": ..., "details": [...]}}.
+type Response struct {
+	Error Body `json:"error"`
+}
+
+// Body describes what went wrong, Details is only set when some fields of the request body are invalid.
+type Body struct {
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Details []FieldDetail `json:"details,omitempty"`
+}
+
+// FieldDetail is one invalid field of the request body, named like its JSON key.
+type FieldDetail struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Abort writes the error envelope and stops the handler chain.
+func Abort(c *gin.Context, status int, code, message string, details ...FieldDetail) {
+	c.AbortWithStatusJSON(status, Response{Error: Body{Code: code, Message: message, Details: details}})
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIKeyHeader carries the API key of a request.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeys authenticates the requests sending one of its keys in the X-API-Key header.
+// The keys are kept as SHA-256 hashes, so looking one up takes the same time whatever its prefix.
+type APIKeys struct {
+	principals map[[sha256.Size]byte]Principal
+}
+
+// NewAPIKeys returns an authenticator with no keys, see Add.
+func NewAPIKeys() *APIKeys {
+	return &APIKeys{principals: make(map[[sha256.Size]byte]Principal)}
+}
+
+// Add makes key authenticate as principal.
+func (keys *APIKeys) Add(key string, principal Principal) {
+	keys.principals[sha256.Sum256([]byte(key))] = principal
+}
+
+// Len returns the number of keys.
+func (keys *APIKeys) Len() int {
+	return len(keys.principals)
+}
+
+func (keys *APIKeys) Authenticate(r *http.Request) (Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return Principal{}, ErrNoCredentials
+	}
+	principal, ok := keys.principals[sha256.Sum256([]byte(key))]
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	}
+	return principal, nil
+}
+
+// ParseAPIKeys reads keys written as "subject:key:role1|role2", separated by commas,
+// like "alice:s3cret:editor,reports:0ther:". A key may have no roles, it then only reads.
+func ParseAPIKeys(spec string) (*APIKeys, error) {
+	keys := NewAPIKeys()
+	for i, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		subject, rest, ok := strings.Cut(entry, ":")
+		key, roles, _ := strings.Cut(rest, ":")
+		if !ok || subject == "" || key == "" {
+			return nil, fmt.Errorf("API key entry %d isn't written as subject:key:roles", i+1)
+		}
+		principal := Principal{Subject: subject}
+		for role := range strings.SplitSeq(roles, "|") {
+			if role = strings.TrimSpace(role); role != "" {
+				principal.Roles = append(principal.Roles, role)
+			}
+		}
+		keys.Add(key, principal)
+	}
+	return keys, nil
+}
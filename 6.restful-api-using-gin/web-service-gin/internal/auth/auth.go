@@ -0,0 +1,109 @@
+// Package auth tells who sends a request and what they may do.
+// An Authenticator turns the credentials of a request into a Principal, Authenticate runs it on every
+// request and RequireRole guards the routes which need a role.
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"example/web-service-gin/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleEditor may create, update and delete albums.
+const RoleEditor = "editor"
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request carries none of its credentials.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned by an Authenticator when the credentials of the request are wrong or expired.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is the caller of a request.
+type Principal struct {
+	Subject string
+	Roles   []string
+}
+
+// HasRole reports whether the principal was granted role.
+func (p Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// Authenticator identifies the caller of a request from its credentials.
+// It returns ErrNoCredentials when there are none it understands and an error wrapping
+// ErrInvalidCredentials when they don't check out.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+// Chain tries each authenticator in turn, the first one finding its credentials in the request decides.
+type Chain []Authenticator
+
+func (chain Chain) Authenticate(r *http.Request) (Principal, error) {
+	for _, authenticator := range chain {
+		principal, err := authenticator.Authenticate(r)
+		if !errors.Is(err, ErrNoCredentials) {
+			return principal, err
+		}
+	}
+	return Principal{}, ErrNoCredentials
+}
+
+// principalKey is the gin context key of the principal.
+const principalKey = "principal"
+
+// Authenticate stores the caller found by authenticator in the context, see PrincipalFrom.
+// A request without credentials goes on anonymously, one with invalid credentials is a 401
+// even on a public route, so a client finds out about a broken key right away.
+func Authenticate(authenticator Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authenticator.Authenticate(c.Request)
+		switch {
+		case errors.Is(err, ErrNoCredentials):
+		case err != nil:
+			unauthorized(c, err.Error())
+			return
+		default:
+			c.Set(principalKey, principal)
+		}
+		c.Next()
+	}
+}
+
+// PrincipalFrom returns the caller stored by Authenticate, false for an anonymous request.
+func PrincipalFrom(c *gin.Context) (Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return Principal{}, false
+	}
+	principal, ok := value.(Principal)
+	return principal, ok
+}
+
+// RequireRole lets through the callers granted role, an anonymous request is a 401
+// and a caller without the role a 403.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := PrincipalFrom(c)
+		if !ok {
+			unauthorized(c, "authentication required, send an API key or a bearer token")
+			return
+		}
+		if !principal.HasRole(role) {
+			apierror.Abort(c, http.StatusForbidden, apierror.CodeForbidden, principal.Subject+" lacks the "+role+" role")
+			return
+		}
+		c.Next()
+	}
+}
+
+// unauthorized answers 401 with the challenge of the bearer scheme.
+func unauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="albums"`)
+	apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, message)
+}
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"example/web-service-gin/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testSecret = []byte(strings.Repeat("s", MinSecretLength))
+
+func newTestJWT(t *testing.T) *JWT {
+	t.Helper()
+	verifier, err := NewJWT(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier.Issuer = "albums-test"
+	return verifier
+}
+
+// newTestRouter serves a public GET and a POST guarded by the editor role,
+// authenticated with the keys and tokens of newTestJWT.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	keys, err := ParseAPIKeys("alice:editor-key:editor, bob:reader-key:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.Use(Authenticate(Chain{keys, newTestJWT(t)}))
+	router.GET("/albums", func(c *gin.Context) {
+		principal, _ := PrincipalFrom(c)
+		c.String(http.StatusOK, principal.Subject)
+	})
+	router.POST("/albums", RequireRole(RoleEditor), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	return router
+}
+
+// sign returns a token for claims signed with method and key.
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims jwt.Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAuthenticateAndRequireRole(t *testing.T) {
+	verifier := newTestJWT(t)
+	editorToken, err := verifier.Sign("carol", []string{RoleEditor}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readerToken, err := verifier.Sign("dave", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		header     string
+		value      string
+		wantStatus int
+		wantCode   string
+	}{
+		{"Anonymous read", http.MethodGet, "", "", http.StatusOK, ""},
+		{"Anonymous write", http.MethodPost, "", "", http.StatusUnauthorized, apierror.CodeUnauthorized},
+		{"Editor key", http.MethodPost, APIKeyHeader, "editor-key", http.StatusCreated, ""},
+		{"Reader key", http.MethodPost, APIKeyHeader, "reader-key", http.StatusForbidden, apierror.CodeForbidden},
+		{"Unknown key on a read", http.MethodGet, APIKeyHeader, "nope", http.StatusUnauthorized, apierror.CodeUnauthorized},
+		{"Editor token", http.MethodPost, "Authorization", "Bearer " + editorToken, http.StatusCreated, ""},
+		{"Reader token", http.MethodPost, "Authorization", "Bearer " + readerToken, http.StatusForbidden, apierror.CodeForbidden},
+		{"Broken token", http.MethodPost, "Authorization", "Bearer " + editorToken + "x", http.StatusUnauthorized, apierror.CodeUnauthorized},
+		{"Other scheme", http.MethodPost, "Authorization", "Basic YWxpY2U6c2VjcmV0", http.StatusUnauthorized, apierror.CodeUnauthorized},
+	}
+
+	router := newTestRouter(t)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/albums", nil)
+			if tc.header != "" {
+				req.Header.Set(tc.header, tc.value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d; want %d, body %s", rec.Code, tc.wantStatus, rec.Body)
+			}
+			if tc.wantCode == "" {
+				return
+			}
+			var body apierror.Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error.Code != tc.wantCode {
+				t.Errorf("body %s; want the %s envelope", rec.Body, tc.wantCode)
+			}
+			if tc.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("a 401 without a WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestJWT_Rejects(t *testing.T) {
+	verifier := newTestJWT(t)
+	now := time.Now()
+	valid := func() *Claims {
+		return &Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "carol",
+				Issuer:    "albums-test",
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			},
+			Roles: []string{RoleEditor},
+		}
+	}
+	with := func(change func(*Claims)) *Claims {
+		claims := valid()
+		change(claims)
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"Expired", sign(t, jwt.SigningMethodHS256, testSecret, with(func(c *Claims) {
+			c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Hour))
+		}))},
+		{"Not valid yet", sign(t, jwt.SigningMethodHS256, testSecret, with(func(c *Claims) {
+			c.NotBefore = jwt.NewNumericDate(now.Add(time.Hour))
+		}))},
+		{"No expiry", sign(t, jwt.SigningMethodHS256, testSecret, with(func(c *Claims) { c.ExpiresAt = nil }))},
+		{"No subject", sign(t, jwt.SigningMethodHS256, testSecret, with(func(c *Claims) { c.Subject = "" }))},
+		{"Other issuer", sign(t, jwt.SigningMethodHS256, testSecret, with(func(c *Claims) { c.Issuer = "someone-else" }))},
+		{"Other secret", sign(t, jwt.SigningMethodHS256, []byte(strings.Repeat("x", MinSecretLength)), valid())},
+		{"Other algorithm", sign(t, jwt.SigningMethodHS512, testSecret, valid())},
+		{"No signature", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid())},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/albums", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			if _, err := verifier.Authenticate(req); !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("Authenticate() error = %v; want ErrInvalidCredentials", err)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/albums", nil)
+	req.Header.Set("Authorization", "Bearer "+sign(t, jwt.SigningMethodHS256, testSecret, valid()))
+	principal, err := verifier.Authenticate(req)
+	if err != nil || principal.Subject != "carol" || !principal.HasRole(RoleEditor) {
+		t.Errorf("Authenticate() of a valid token = %+v, %v; want carol the editor", principal, err)
+	}
+}
+
+func TestNewJWT_ShortSecret(t *testing.T) {
+	if _, err := NewJWT([]byte("too short")); err == nil {
+		t.Error("NewJWT() accepted a 9 byte secret")
+	}
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys("alice:k1:editor|admin,, bob:k2:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.Len() != 2 {
+		t.Errorf("Len() = %d; want 2", keys.Len())
+	}
+	req := httptest.NewRequest(http.MethodGet, "/albums", nil)
+	req.Header.Set(APIKeyHeader, "k1")
+	if principal, err := keys.Authenticate(req); err != nil || principal.Subject != "alice" || !principal.HasRole("admin") {
+		t.Errorf("Authenticate(k1) = %+v, %v; want alice with the admin role", principal, err)
+	}
+
+	for _, spec := range []string{"alice", "alice:", ":k1:editor"} {
+		if _, err := ParseAPIKeys(spec); err == nil {
+			t.Errorf("ParseAPIKeys(%q) accepted a key without a subject or a key", spec)
+		}
+	}
+}
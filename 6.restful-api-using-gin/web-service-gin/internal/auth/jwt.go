@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MinSecretLength is the shortest HMAC secret accepted, the size of the SHA-256 output.
+const MinSecretLength = 32
+
+// Claims are the claims of the tokens JWT verifies and signs: the registered ones and the roles of the subject.
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles,omitempty"`
+}
+
+// JWT authenticates the requests sending an "Authorization: Bearer <token>" header with a JSON Web Token
+// signed with HS256 by the shared secret. The token has to carry a subject and an expiry,
+// and the issuer and audience when they are set.
+type JWT struct {
+	secret   []byte
+	Issuer   string
+	Audience string
+	// Leeway absorbs the clock skew between the server and the issuer of the tokens.
+	Leeway time.Duration
+}
+
+// NewJWT returns an authenticator of the tokens signed with secret, which has to be at least MinSecretLength bytes.
+func NewJWT(secret []byte) (*JWT, error) {
+	if len(secret) < MinSecretLength {
+		return nil, fmt.Errorf("the JWT secret has %d bytes, it needs at least %d", len(secret), MinSecretLength)
+	}
+	return &JWT{secret: secret, Leeway: time.Minute}, nil
+}
+
+func (j *JWT) Authenticate(r *http.Request) (Principal, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return Principal{}, ErrNoCredentials
+	}
+
+	options := []jwt.ParserOption{
+		// Only HS256, a token can't pick another algorithm, "none" included.
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(j.Leeway),
+	}
+	if j.Issuer != "" {
+		options = append(options, jwt.WithIssuer(j.Issuer))
+	}
+	if j.Audience != "" {
+		options = append(options, jwt.WithAudience(j.Audience))
+	}
+
+	var claims Claims
+	if _, err := jwt.ParseWithClaims(strings.TrimSpace(token), &claims, func(*jwt.Token) (any, error) {
+		return j.secret, nil
+	}, options...); err != nil {
+		return Principal{}, fmt.Errorf("%w: %s", ErrInvalidCredentials, tokenProblem(err))
+	}
+	if claims.Subject == "" {
+		return Principal{}, fmt.Errorf("%w: the token has no subject", ErrInvalidCredentials)
+	}
+	return Principal{Subject: claims.Subject, Roles: claims.Roles}, nil
+}
+
+// Sign returns a token for subject with roles, valid for ttl, which Authenticate accepts.
+func (j *JWT) Sign(subject string, roles []string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    j.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Roles: roles,
+	}
+	if j.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{j.Audience}
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secret)
+}
+
+// tokenProblem tells the client why its token was refused, without the details of the parser.
+func tokenProblem(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "the token expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return "the token isn't valid yet"
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return "the token has no expiry"
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer), errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return "the token wasn't issued for this API"
+	default:
+		return "the token is malformed or its signature is wrong"
+	}
+}
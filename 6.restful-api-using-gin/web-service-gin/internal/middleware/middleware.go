@@ -0,0 +1,191 @@
+// Package middleware holds the gin middlewares wrapped around every route of the server.
+// Each one stands on its own, cmd/api chains them in the order of Chain.
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"example/web-service-gin/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config selects what the middlewares allow.
+type Config struct {
+	// AllowedOrigins are the origins a browser may call the API from, like "https://app.example.com".
+	// "*" allows every origin, an empty list none.
+	AllowedOrigins []string
+	// MaxBodyBytes bounds the size of a request body, zero or less means no limit.
+	MaxBodyBytes int64
+}
+
+// Chain returns every middleware in the order they have to run:
+// the request id first so everything after it can log it, the access log around the recovery
+// so a panic is logged with its 500, then the checks which may reject the request.
+func Chain(logger *slog.Logger, cfg Config) []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		RequestID(),
+		AccessLog(logger),
+		Recovery(logger),
+		CORS(cfg.AllowedOrigins),
+		BodyLimit(cfg.MaxBodyBytes),
+	}
+}
+
+// RequestIDHeader is echoed on every response, a proxy in front of the server may set it on the request.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the gin context key of the request id.
+const requestIDKey = "request_id"
+
+// clientRequestID matches the ids kept from the request: 1 to 128 visible ASCII characters.
+var clientRequestID = regexp.MustCompile(`^[!-~]{1,128}$`)
+
+// RequestID keeps the X-Request-ID of the request or makes up one, see RequestIDFrom.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !clientRequestID.MatchString(id) {
+			id = fmt.Sprintf("%016x", rand.Uint64())
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// RequestIDFrom returns the id set by RequestID, an empty string without it.
+func RequestIDFrom(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// AccessLog writes one record per request once it is served, at the error level for the 5xx responses.
+func AccessLog(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		level := slog.LevelInfo
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("request_id", RequestIDFrom(c)),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			// The route is the pattern like /albums/:id, empty when no route matched.
+			slog.String("route", c.FullPath()),
+			slog.Int("status", c.Writer.Status()),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypeAny); len(errs) > 0 {
+			attrs = append(attrs, slog.String("error", errs.String()))
+		}
+		logger.LogAttrs(c.Request.Context(), level, "request served", attrs...)
+	}
+}
+
+// Recovery turns a panic of a handler into a 500 answered with the error envelope, and logs it with its stack.
+func Recovery(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// net/http uses this panic to abort a response on purpose, it isn't a bug.
+			if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(recovered)
+			}
+
+			logger.ErrorContext(c.Request.Context(), "handler panicked",
+				slog.String("request_id", RequestIDFrom(c)),
+				slog.Any("panic", recovered),
+				slog.String("stack", string(debug.Stack())))
+			c.Error(fmt.Errorf("panic: %v", recovered))
+			if c.Writer.Written() {
+				// Too late for the envelope, the status and maybe part of the body are already sent.
+				c.Abort()
+				return
+			}
+			apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "internal server error")
+		}()
+		c.Next()
+	}
+}
+
+// The CORS headers sent back to the allowed origins.
+var (
+	corsMethods       = strings.Join([]string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}, ", ")
+	corsHeaders       = strings.Join([]string{"Content-Type", "Authorization", "X-API-Key", RequestIDHeader}, ", ")
+	corsExposeHeaders = strings.Join([]string{"Location", RequestIDHeader}, ", ")
+	corsMaxAge        = strconv.Itoa(int((10 * time.Minute).Seconds()))
+)
+
+// CORS lets the browsers call the API from the allowed origins.
+// A preflight request is answered here, with 204 for an allowed origin and 403 otherwise.
+// Other requests from an origin which isn't allowed are served without CORS headers, so the browser hides the response.
+func CORS(allowedOrigins []string) gin.HandlerFunc {
+	anyOrigin := slices.Contains(allowedOrigins, "*")
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		allowed := anyOrigin || slices.Contains(allowedOrigins, origin)
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !allowed {
+			if preflight {
+				apierror.Abort(c, http.StatusForbidden, apierror.CodeForbidden, fmt.Sprintf("origin %q is not allowed", origin))
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", corsMethods)
+			c.Header("Access-Control-Allow-Headers", corsHeaders)
+			c.Header("Access-Control-Max-Age", corsMaxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Header("Access-Control-Expose-Headers", corsExposeHeaders)
+		c.Next()
+	}
+}
+
+// BodyLimit rejects the request bodies larger than maxBytes with a 413.
+// A body announcing its size is rejected right away, any other one fails the handler reading past the limit,
+// which answers with the same 413 (see the binding of the album controller).
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > maxBytes {
+			apierror.Abort(c, http.StatusRequestEntityTooLarge, apierror.CodeTooLarge,
+				fmt.Sprintf("request body must not be larger than %d bytes", maxBytes))
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example/web-service-gin/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter chains every middleware in front of a few routes, the access log goes to logs as JSON.
+func newTestRouter(t *testing.T, cfg Config, logs *bytes.Buffer) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(Chain(slog.New(slog.NewJSONHandler(logs, nil)), cfg)...)
+	router.GET("/albums/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"request_id": RequestIDFrom(c)})
+	})
+	router.POST("/albums", func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.String(http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	return router
+}
+
+func serve(router http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequestIDAndAccessLog(t *testing.T) {
+	var logs bytes.Buffer
+	router := newTestRouter(t, Config{}, &logs)
+
+	req := httptest.NewRequest(http.MethodGet, "/albums/1", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	rec := serve(router, req)
+	if got := rec.Header().Get(RequestIDHeader); got != "req-42" || !strings.Contains(rec.Body.String(), "req-42") {
+		t.Errorf("request id %q, body %s; want req-42 in both", got, rec.Body)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("access log %q isn't one JSON record: %v", logs.String(), err)
+	}
+	want := map[string]any{"request_id": "req-42", "method": "GET", "path": "/albums/1", "route": "/albums/:id", "status": float64(200), "bytes": float64(rec.Body.Len())}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("access log %s = %v; want %v", key, record[key], value)
+		}
+	}
+	if _, ok := record["latency"]; !ok {
+		t.Errorf("access log %v has no latency", record)
+	}
+
+	// An id which isn't printable ASCII is replaced.
+	req = httptest.NewRequest(http.MethodGet, "/albums/1", nil)
+	req.Header.Set(RequestIDHeader, "two words")
+	if got := serve(router, req).Header().Get(RequestIDHeader); len(got) != 16 {
+		t.Errorf("request id %q; want a generated one", got)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	var logs bytes.Buffer
+	rec := serve(newTestRouter(t, Config{}, &logs), httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	var resp apierror.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusInternalServerError || resp.Error.Code != apierror.CodeInternal {
+		t.Errorf("panicking handler answered %d %s; want 500 with the error envelope", rec.Code, rec.Body)
+	}
+	if !strings.Contains(logs.String(), `"panic":"boom"`) || !strings.Contains(logs.String(), `"status":500`) {
+		t.Errorf("logs %s; want the panic and the 500 access log", logs.String())
+	}
+}
+
+func TestCORS(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantAllowed bool
+	}{
+		{"Same origin", http.MethodGet, "", false, http.StatusOK, false},
+		{"Allowed origin", http.MethodGet, "https://app.example.com", false, http.StatusOK, true},
+		{"Other origin", http.MethodGet, "https://evil.example.com", false, http.StatusOK, false},
+		{"Allowed preflight", http.MethodOptions, "https://app.example.com", true, http.StatusNoContent, true},
+		{"Other preflight", http.MethodOptions, "https://evil.example.com", true, http.StatusForbidden, false},
+	}
+
+	router := newTestRouter(t, Config{AllowedOrigins: []string{"https://app.example.com"}}, &bytes.Buffer{})
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/albums/1", nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			if tc.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+			}
+			rec := serve(router, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("status = %d; want %d", rec.Code, tc.wantStatus)
+			}
+			allowed := rec.Header().Get("Access-Control-Allow-Origin")
+			if (allowed == tc.origin && allowed != "") != tc.wantAllowed {
+				t.Errorf("Access-Control-Allow-Origin = %q; want allowed: %t", allowed, tc.wantAllowed)
+			}
+			if tc.preflight && tc.wantAllowed && !strings.Contains(rec.Header().Get("Access-Control-Allow-Methods"), http.MethodPut) {
+				t.Errorf("preflight allows %q; want PUT among them", rec.Header().Get("Access-Control-Allow-Methods"))
+			}
+		})
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	router := newTestRouter(t, Config{MaxBodyBytes: 10}, &bytes.Buffer{})
+
+	tests := []struct {
+		name       string
+		body       io.Reader
+		wantStatus int
+	}{
+		{"Small body", strings.NewReader("0123456789"), http.StatusCreated},
+		{"Announced large body", strings.NewReader("0123456789A"), http.StatusRequestEntityTooLarge},
+		// Without a known length the limit only trips while the handler reads.
+		{"Streamed large body", io.MultiReader(strings.NewReader("01234"), strings.NewReader("56789A")), http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(router, httptest.NewRequest(http.MethodPost, "/albums", tc.body))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("status = %d; want %d (body %s)", rec.Code, tc.wantStatus, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,3 @@
+module example/money
+
+go 1.25.1
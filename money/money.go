@@ -0,0 +1,251 @@
+// Package money implements an exact, fixed-point amount of money in a given currency.
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency assumed when only an amount is given.
+const DefaultCurrency = "USD"
+
+// Money is an amount of money in minor units of its currency, e.g. {Amount: 5699, Currency: "USD"} is $56.99.
+// The zero value has no currency and is rejected by Validate.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// ErrInvalid is wrapped by every error caused by a malformed amount or currency.
+var ErrInvalid = errors.New("invalid money")
+
+// exponents lists the currencies whose minor unit isn't a hundredth of the major unit.
+// Every other currency has 2 decimal places.
+var exponents = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// Exponent returns the number of decimal places of the currency, e.g. 2 for USD and 0 for JPY.
+func Exponent(currency string) int {
+	if exp, ok := exponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+// New returns the amount of minor units in the given currency.
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Parse reads a decimal amount like "56.99" or "-3" in the given currency.
+// Digits beyond the precision of the currency are only accepted when they are zeros, nothing is ever rounded.
+func Parse(amount, currency string) (Money, error) {
+	if err := validateCurrency(currency); err != nil {
+		return Money{}, err
+	}
+	minor, err := parseMinor(amount, Exponent(currency))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// MustParse is like Parse but panics on an error, it is meant for constants and seed data.
+func MustParse(amount, currency string) Money {
+	m, err := Parse(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func parseMinor(s string, exp int) (int64, error) {
+	invalid := func() (int64, error) {
+		return 0, fmt.Errorf("%w: amount %q is not a decimal number", ErrInvalid, s)
+	}
+
+	digits := strings.TrimPrefix(s, "-")
+	negative := len(digits) != len(s)
+	whole, frac, hasPoint := strings.Cut(digits, ".")
+	if whole == "" || (hasPoint && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return invalid()
+	}
+
+	// Drop the zeros past the precision of the currency, anything else there would need rounding.
+	if len(frac) > exp {
+		if strings.Trim(frac[exp:], "0") != "" {
+			return 0, fmt.Errorf("%w: amount %q has more than %d decimal places", ErrInvalid, s, exp)
+		}
+		frac = frac[:exp]
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: amount %q is out of range", ErrInvalid, s)
+	}
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func validateCurrency(currency string) error {
+	if len(currency) != 3 || strings.ToUpper(currency) != currency || !isLetters(currency) {
+		return fmt.Errorf("%w: currency %q is not an ISO 4217 code like \"USD\"", ErrInvalid, currency)
+	}
+	return nil
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate reports whether the currency is a well formed ISO 4217 code.
+func (m Money) Validate() error {
+	return validateCurrency(m.Currency)
+}
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Decimal returns the amount as a decimal string in major units, e.g. "56.99".
+func (m Money) Decimal() string {
+	exp := Exponent(m.Currency)
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+	}
+	// Formatting the absolute value as unsigned also covers math.MinInt64.
+	digits := strconv.FormatUint(absUint(amount), 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+func absUint(n int64) uint64 {
+	if n < 0 {
+		if n == math.MinInt64 {
+			return uint64(math.MaxInt64) + 1
+		}
+		return uint64(-n)
+	}
+	return uint64(n)
+}
+
+// String returns the amount followed by the currency, e.g. "56.99 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// jsonMoney is the JSON representation of Money.
+type jsonMoney struct {
+	Amount   json.Number `json:"amount"`
+	Currency string      `json:"currency"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.Decimal(), m.Currency})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// It accepts {"amount": "56.99", "currency": "USD"} (the amount may also be a number)
+// and a bare amount like 56.99, which is taken to be in DefaultCurrency. A null is an error,
+// a Money always has an amount: decode into a *Money for an optional one.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v jsonMoney
+	switch trimmed := strings.TrimSpace(string(data)); {
+	case trimmed == "null":
+		return fmt.Errorf("%w: amount is null", ErrInvalid)
+	case strings.HasPrefix(trimmed, "{"):
+		if err := json.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+	default:
+		if err := json.Unmarshal(data, &v.Amount); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		v.Currency = DefaultCurrency
+	}
+
+	parsed, err := Parse(v.Amount.String(), v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, the amount is written as a decimal string for a DECIMAL column.
+// The currency isn't part of it and has to be stored in a column of its own.
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
+
+// Scan implements sql.Scanner for a DECIMAL column holding the amount in major units.
+// The number of minor units depends on the currency, so m.Currency has to be set (or scanned) first,
+// DefaultCurrency is assumed when it is empty.
+func (m *Money) Scan(src any) error {
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	exp := Exponent(m.Currency)
+
+	var (
+		minor int64
+		err   error
+	)
+	switch v := src.(type) {
+	case []byte:
+		minor, err = parseMinor(string(v), exp)
+	case string:
+		minor, err = parseMinor(v, exp)
+	case int64:
+		minor, err = parseMinor(strconv.FormatInt(v, 10), exp)
+	case float64:
+		// Some drivers (e.g. SQLite for a column with NUMERIC affinity) hand DECIMAL values back as floats.
+		// A float64 only holds about 15 significant digits, larger amounts come back rounded,
+		// store them in a TEXT column to keep them exact.
+		minor, err = parseMinor(strconv.FormatFloat(v, 'f', -1, 64), exp)
+	default:
+		return fmt.Errorf("%w: can't scan %T into money", ErrInvalid, src)
+	}
+	if err != nil {
+		return err
+	}
+	m.Amount = minor
+	return nil
+}
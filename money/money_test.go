@@ -0,0 +1,167 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     Money
+	}{
+		{"Cents", "56.99", "USD", New(5699, "USD")},
+		{"Whole amount", "399", "USD", New(39900, "USD")},
+		{"One decimal", "399.3", "USD", New(39930, "USD")},
+		{"Trailing zeros", "56.9900", "USD", New(5699, "USD")},
+		{"Negative", "-0.05", "EUR", New(-5, "EUR")},
+		{"No minor unit", "1500", "JPY", New(1500, "JPY")},
+		{"Three decimals", "1.234", "KWD", New(1234, "KWD")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(tc.amount, tc.currency)
+			if err != nil {
+				t.Fatalf("Parse(%q, %q) error = %v", tc.amount, tc.currency, err)
+			}
+			if got != tc.want {
+				t.Errorf("Parse(%q, %q) = %+v; want %+v", tc.amount, tc.currency, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+	}{
+		{"Needs rounding", "56.999", "USD"},
+		{"Fraction of a yen", "1500.5", "JPY"},
+		{"Not a number", "abc", "USD"},
+		{"Exponent", "1e3", "USD"},
+		{"Missing digits", "5.", "USD"},
+		{"Overflow", "99999999999999999999", "USD"},
+		{"Lowercase currency", "1.00", "usd"},
+		{"Empty currency", "1.00", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Parse(tc.amount, tc.currency); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Parse(%q, %q) error = %v; want ErrInvalid", tc.amount, tc.currency, err)
+			}
+		})
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{New(5699, "USD"), "56.99"},
+		{New(5, "USD"), "0.05"},
+		{New(-5, "USD"), "-0.05"},
+		{New(0, "USD"), "0.00"},
+		{New(1500, "JPY"), "1500"},
+		{New(1234, "KWD"), "1.234"},
+	}
+
+	for _, tc := range tests {
+		if got := tc.money.Decimal(); got != tc.want {
+			t.Errorf("%+v.Decimal() = %q; want %q", tc.money, got, tc.want)
+		}
+	}
+}
+
+func TestJSON_RoundTrip(t *testing.T) {
+	price := New(39931, "USD")
+
+	data, err := json.Marshal(price)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":"399.31","currency":"USD"}`; string(data) != want {
+		t.Errorf("json.Marshal() = %s; want %s", data, want)
+	}
+
+	var got Money
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if got != price {
+		t.Errorf("json.Unmarshal() = %+v; want %+v", got, price)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want Money
+	}{
+		{"Object with a numeric amount", `{"amount": 399.31, "currency": "EUR"}`, New(39931, "EUR")},
+		{"Bare number", `399.31`, New(39931, "USD")},
+		{"Bare string", `"0.10"`, New(10, "USD")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got Money
+			if err := json.Unmarshal([]byte(tc.json), &got); err != nil {
+				t.Fatalf("json.Unmarshal(%s) error = %v", tc.json, err)
+			}
+			if got != tc.want {
+				t.Errorf("json.Unmarshal(%s) = %+v; want %+v", tc.json, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSON_Invalid(t *testing.T) {
+	for _, data := range []string{`null`, `{"amount": null, "currency": "USD"}`, `"abc"`, `{"amount": 1, "currency": "XX"}`} {
+		var got Money
+		if err := json.Unmarshal([]byte(data), &got); !errors.Is(err, ErrInvalid) {
+			t.Errorf("json.Unmarshal(%s) error = %v; want ErrInvalid", data, err)
+		}
+	}
+
+	// A *Money is the way to make the amount optional, null leaves it nil.
+	var optional struct{ Price *Money }
+	if err := json.Unmarshal([]byte(`{"Price": null}`), &optional); err != nil || optional.Price != nil {
+		t.Errorf("json.Unmarshal() of a null *Money = %v, %v; want nil", optional.Price, err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		src      any
+		want     Money
+	}{
+		{"MySQL DECIMAL bytes", "USD", []byte("56.9900"), New(5699, "USD")},
+		{"SQLite REAL", "USD", 56.99, New(5699, "USD")},
+		{"SQLite INTEGER", "USD", int64(17), New(1700, "USD")},
+		{"Currency scanned first", "JPY", []byte("1500.0000"), New(1500, "JPY")},
+		{"No currency yet", "", "0.10", New(10, "USD")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Money{Currency: tc.currency}
+			if err := got.Scan(tc.src); err != nil {
+				t.Fatalf("Scan(%v) error = %v", tc.src, err)
+			}
+			if got != tc.want {
+				t.Errorf("Scan(%v) = %+v; want %+v", tc.src, got, tc.want)
+			}
+		})
+	}
+}